@@ -0,0 +1,216 @@
+package beater
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/beat"
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/libbeat/logp"
+	cfg "github.com/elastic/beats/swiftbeat/config"
+	"github.com/elastic/beats/swiftbeat/indexer"
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+// ScanArgs holds the command line flags of the offline one-shot scan
+type ScanArgs struct {
+	Path       *string
+	Type       *string
+	Partitions *string
+	Output     *string
+	SwiftDir   *string
+	Config     *string
+}
+
+var scanArgs ScanArgs
+
+func init() {
+	scanArgs = ScanArgs{
+		Path:       flag.String("scan", "", "Scan the disk, resource or partition dir once, print the events and exit"),
+		Type:       flag.String("scan-type", "", "Resource type to scan: account, container or object"),
+		Partitions: flag.String("scan-partitions", "", "Comma separated list of partitions to scan"),
+		Output:     flag.String("scan-output", "-", "File to write the scan events to, - for stdout"),
+		SwiftDir:   flag.String("scan-swift-dir", indexer.DefaultSwiftConfig.SwiftDir, "Swift configuration dir used by the scan"),
+		Config:     flag.String("scan-config", "", "YAML file with the indexer settings used by the scan"),
+	}
+
+	beat.AddFlagsCallback(func(b *beat.Beat) error {
+		if *scanArgs.Path == "" {
+			return nil
+		}
+
+		if err := runScan(b.Name, scanArgs); err != nil {
+			return fmt.Errorf("Scan of %s failed: %v", *scanArgs.Path, err)
+		}
+		return beat.GracefulExit
+	})
+}
+
+// runScan indexes the given path once without the registry and writes the
+// events as JSON lines. Indexer logs are written to stderr with -e
+func runScan(name string, args ScanArgs) error {
+	toFiles := false
+	if err := logp.Init(name, &logp.Logging{ToFiles: &toFiles}); err != nil {
+		return err
+	}
+	logp.SetStderr()
+
+	devPath, resources, partitions, err := scanTarget(*args.Path)
+	if err != nil {
+		return err
+	}
+
+	if resType := *args.Type; resType != "" {
+		if _, ok := cfg.ValidResourceType[resType]; !ok {
+			return fmt.Errorf("invalid resource type: %s", resType)
+		}
+		if len(resources) == 0 {
+			resources = []string{resType}
+		} else if resourceType(resources[0]) != resType {
+			return fmt.Errorf("%s is not a %s dir", *args.Path, resType)
+		}
+	}
+
+	if *args.Partitions != "" {
+		if len(partitions) > 0 {
+			return errors.New("partitions can not be selected for a partition dir")
+		}
+		for _, s := range strings.Split(*args.Partitions, ",") {
+			partId, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid partition: %s", s)
+			}
+			partitions = append(partitions, partId)
+		}
+	}
+
+	indexerCfg, err := scanConfig(*args.Config, resources, partitions)
+	if err != nil {
+		return err
+	}
+
+	var out io.Writer = os.Stdout
+	if *args.Output != "-" {
+		f, err := os.Create(*args.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+
+	swiftConf := indexer.DefaultSwiftConfig
+	swiftConf.SwiftDir = *args.SwiftDir
+
+	// no partition state is given since the registry is not used, which
+	// always results in a full scan
+	eventChan := make(chan input.Event)
+	disk, err := indexer.NewDisk(filepath.Base(devPath), devPath, swiftConf,
+		indexerCfg, eventChan, make(chan struct{}), nil, nil, 0)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		disk.BuildIndex()
+		disk.Wait()
+		close(eventChan)
+	}()
+
+	return writeEvents(out, eventChan)
+}
+
+// writeEvents writes the events one JSON document per line until the
+// channel is closed
+func writeEvents(out io.Writer, events <-chan input.Event) error {
+	w := bufio.NewWriter(out)
+
+	var err error
+	for event := range events {
+		// keep draining so the scan is not blocked
+		if err != nil {
+			continue
+		}
+		_, err = fmt.Fprintln(w, event.ToMapStr().String())
+	}
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// scanTarget resolves the path into the disk path with the resource dir
+// and partition selected, if the path points to any of them
+func scanTarget(path string) (string, []string, []int64, error) {
+	path = filepath.Clean(path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	if !info.IsDir() {
+		return "", nil, nil, fmt.Errorf("%s is not a dir", path)
+	}
+
+	name := filepath.Base(path)
+	parent := filepath.Dir(path)
+	if isResourceDir(name) {
+		return parent, []string{name}, nil, nil
+	}
+
+	resName := filepath.Base(parent)
+	if partId, err := strconv.ParseInt(name, 10, 64); err == nil && isResourceDir(resName) {
+		return filepath.Dir(parent), []string{resName}, []int64{partId}, nil
+	}
+
+	return path, nil, nil, nil
+}
+
+// scanConfig returns the indexer settings of the scan, devices are indexed
+// even if not found in the ring since the disk might be examined off the host
+func scanConfig(path string, resources []string, partitions []int64) (*common.Config, error) {
+	defaults, err := common.NewConfigFrom(map[string]interface{}{
+		"not_in_ring": "index",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	configs := []*common.Config{defaults}
+	if path != "" {
+		fileCfg, err := common.LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, fileCfg)
+	}
+
+	selected, err := common.NewConfigFrom(map[string]interface{}{
+		"resources":  resources,
+		"partitions": partitions,
+	})
+	if err != nil {
+		return nil, err
+	}
+	configs = append(configs, selected)
+
+	return common.MergeConfigs(configs...)
+}
+
+// helper function to tell whether the dir holds partitions of a resource
+func isResourceDir(name string) bool {
+	return name == "accounts" || name == "containers" ||
+		name == "objects" || strings.HasPrefix(name, "objects-")
+}
+
+// resourceType returns the resource type of the resource dir
+func resourceType(name string) string {
+	return strings.TrimSuffix(strings.SplitN(name, "-", 2)[0], "s")
+}
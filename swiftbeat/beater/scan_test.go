@@ -0,0 +1,80 @@
+// +build !integration
+
+package beater
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScanTarget(t *testing.T) {
+	dir, err := ioutil.TempDir("", "swiftbeat-scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	disk := filepath.Join(dir, "sdb")
+	for _, path := range []string{"accounts/12", "objects/7", "objects-1/7", "objects/tmp"} {
+		if err := os.MkdirAll(filepath.Join(disk, path), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ioutil.WriteFile(filepath.Join(disk, "objects", "7", "hashes.pkl"), nil, 0644)
+
+	tests := []struct {
+		path       string
+		devPath    string
+		resources  []string
+		partitions []int64
+		err        bool
+	}{
+		{path: disk, devPath: disk},
+		{path: disk + "/", devPath: disk},
+		{path: filepath.Join(disk, "accounts"), devPath: disk, resources: []string{"accounts"}},
+		{path: filepath.Join(disk, "objects-1"), devPath: disk, resources: []string{"objects-1"}},
+		{path: filepath.Join(disk, "accounts", "12"), devPath: disk,
+			resources: []string{"accounts"}, partitions: []int64{12}},
+		{path: filepath.Join(disk, "objects-1", "7"), devPath: disk,
+			resources: []string{"objects-1"}, partitions: []int64{7}},
+		// not a partition dir, taken as a disk
+		{path: filepath.Join(disk, "objects", "tmp"), devPath: filepath.Join(disk, "objects", "tmp")},
+		{path: filepath.Join(disk, "objects", "7", "hashes.pkl"), err: true},
+		{path: filepath.Join(disk, "missing"), err: true},
+	}
+
+	for _, test := range tests {
+		devPath, resources, partitions, err := scanTarget(test.path)
+		if test.err {
+			assert.Error(t, err, test.path)
+			continue
+		}
+		assert.NoError(t, err, test.path)
+		assert.Equal(t, test.devPath, devPath, test.path)
+		assert.Equal(t, test.resources, resources, test.path)
+		assert.Equal(t, test.partitions, partitions, test.path)
+	}
+}
+
+func TestResourceType(t *testing.T) {
+	tests := []struct {
+		name    string
+		resType string
+	}{
+		{"accounts", "account"},
+		{"containers", "container"},
+		{"objects", "object"},
+		{"objects-12", "object"},
+	}
+
+	for _, test := range tests {
+		assert.True(t, isResourceDir(test.name), test.name)
+		assert.Equal(t, test.resType, resourceType(test.name), test.name)
+	}
+	assert.False(t, isResourceDir("tmp"))
+	assert.False(t, isResourceDir("async_pending"))
+}
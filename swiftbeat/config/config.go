@@ -81,11 +81,11 @@ func mergeConfigFiles(configFiles []string, config *Config) error {
 		logp.Info("Additional configs loaded from: %s", file)
 
 		tmpConfig := struct {
-			Filebeat Config
+			Swiftbeat Config
 		}{}
 		cfgfile.Read(&tmpConfig, file)
 
-		config.Prospectors = append(config.Prospectors, tmpConfig.Filebeat.Prospectors...)
+		config.Prospectors = append(config.Prospectors, tmpConfig.Swiftbeat.Prospectors...)
 	}
 
 	return nil
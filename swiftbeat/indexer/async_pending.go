@@ -0,0 +1,104 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	pickle "github.com/hydrogen18/stalecucumber"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// indexAsyncPending reports container updates the object server failed to
+// send synchronously, one event per suffix dir with the oldest update
+// unpickled as sample. Backlog here usually means overloaded container servers
+func (d *Disk) indexAsyncPending(path string) {
+	logp.Debug("indexer", "Start indexing async pending dir: %s", path)
+
+	// async pendings for non-default policies are kept in async_pending-N
+	_, policy, err := parseResourceName(filepath.Base(path))
+	if err != nil {
+		logp.Warn("skip async pending dir(%s): %v", path, err)
+		return
+	}
+
+	suffixes, err := readDir(d.limiter, path)
+	if err != nil {
+		logp.Err("list dir(%s) failed: %v", path, err)
+		return
+	}
+
+	for _, suffix := range suffixes {
+		if !suffix.IsDir() || !isSuffixName(suffix.Name()) {
+			continue
+		}
+		if d.stopped() {
+			return
+		}
+
+		suffixPath := filepath.Join(path, suffix.Name())
+		updates, err := readDir(d.limiter, suffixPath)
+		if err != nil {
+			logp.Err("list dir(%s) failed: %v", suffixPath, err)
+			continue
+		}
+
+		pending := swift.AsyncPending{
+			Device:      d.Name,
+			PolicyIndex: policy,
+			Suffix:      suffix.Name(),
+			Path:        suffixPath,
+		}
+
+		var oldest os.FileInfo
+		for _, update := range updates {
+			if !update.Mode().IsRegular() {
+				continue
+			}
+			pending.NumUpdates += 1
+			if oldest == nil || update.ModTime().Before(oldest.ModTime()) {
+				oldest = update
+			}
+		}
+
+		// emptied suffix dirs are removed by the updater later on
+		if oldest == nil {
+			continue
+		}
+
+		pending.OldestMtime = oldest.ModTime()
+		pending.SamplePath = filepath.Join(suffixPath, oldest.Name())
+		d.sampleAsyncPending(&pending)
+		pending.ScannedAt = time.Now()
+
+		if !d.publish(input.NewAsyncPendingEvent(pending)) {
+			return
+		}
+	}
+}
+
+// sampleAsyncPending unpickles the container update of the sample file
+func (d *Disk) sampleAsyncPending(pending *swift.AsyncPending) {
+	d.limiter.wait()
+
+	f, err := os.Open(pending.SamplePath)
+	if err != nil {
+		logp.Err("open file(%s) failed: %v", pending.SamplePath, err)
+		return
+	}
+	defer f.Close()
+
+	dict, err := pickle.DictString(pickle.Unpickle(f))
+	if err != nil {
+		logp.Err("unpickling file(%s) failed: %v", pending.SamplePath, err)
+		return
+	}
+
+	pending.SampleOp, _ = dict["op"].(string)
+	pending.SampleAccount, _ = dict["account"].(string)
+	pending.SampleContainer, _ = dict["container"].(string)
+	pending.SampleObject, _ = dict["obj"].(string)
+}
@@ -0,0 +1,70 @@
+// +build !integration
+
+package indexer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pickle "github.com/hydrogen18/stalecucumber"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsyncPending(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	var buf bytes.Buffer
+	update := map[string]string{"op": "PUT", "account": "a", "container": "c", "obj": "o"}
+	if _, err := pickle.NewPickler(&buf).Pickle(update); err != nil {
+		t.Fatal(err)
+	}
+
+	// the oldest update of a suffix is sampled
+	dir := node.mkdir(t, "async_pending-1", "abc")
+	oldest := time.Now().Add(-10 * time.Hour)
+	for i, name := range []string{"d41d8cd98f00b204e9800998ecf8427e-1", "d41d8cd98f00b204e9800998ecf8427e-2"} {
+		path := filepath.Join(dir, name)
+		data := buf.Bytes()
+		if i > 0 {
+			data = []byte("garbage")
+		}
+		if err := ioutil.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := oldest.Add(time.Duration(i) * time.Hour)
+		os.Chtimes(path, mtime, mtime)
+	}
+	// emptied suffix dirs and non suffix dirs are left out
+	node.mkdir(t, "async_pending-1", "def")
+	node.mkdir(t, "async_pending-1", "tmp")
+	// a sample that can't be unpickled is dropped
+	writeFile(t, filepath.Join(node.mkdir(t, "async_pending", "fed"), "update"), 10)
+
+	events := node.scan(t, map[string]interface{}{"enable_async_pending_index": true}, nil)
+
+	pendings := eventsOf(events, "async_pending")
+	assert.Len(t, pendings, 2)
+	for _, p := range pendings {
+		switch p["suffix"] {
+		case "abc":
+			assert.Equal(t, int64(1), p["policy_index"])
+			assert.Equal(t, int64(2), p["num_updates"])
+			assert.InDelta(t, 10, p["oldest_age_hours"], 0.1)
+			assert.Equal(t, "PUT", p["sample_op"])
+			assert.Equal(t, "a", p["sample_account"])
+			assert.Equal(t, "c", p["sample_container"])
+			assert.Equal(t, "o", p["sample_object"])
+		case "fed":
+			assert.Equal(t, int64(0), p["policy_index"])
+			assert.Equal(t, int64(1), p["num_updates"])
+			assert.NotContains(t, p, "sample_container")
+		default:
+			t.Errorf("unexpected suffix: %v", p["suffix"])
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package indexer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// consistency audit findings on object hash dirs
+const (
+	AuditDataWithNewerTombstone = "data_with_newer_tombstone"
+	AuditMultipleDatafiles      = "multiple_datafiles"
+	AuditOrphanedMeta           = "orphaned_meta"
+	AuditZeroByteDatafile       = "zero_byte_datafile"
+	AuditMissingMetadata        = "missing_metadata"
+	AuditCorruptMetadata        = "corrupt_metadata"
+	AuditContentLengthMismatch  = "content_length_mismatch"
+)
+
+// helper function to parse the timestamp from an object file name
+// fragment archives of EC policies are named <timestamp>#<frag_index>[#d]
+func fileTimestamp(name string) float64 {
+	base := strings.TrimSuffix(name, filepath.Ext(name))
+	if i := strings.Index(base, "#"); i >= 0 {
+		base = base[:i]
+	}
+	ts, err := strconv.ParseFloat(base, 64)
+	if err != nil {
+		return -1
+	}
+	return ts
+}
+
+// helper function to return the newest file by timestamp in the file name
+func newestFile(files []*FileRecord) *FileRecord {
+	var newest *FileRecord
+	for _, file := range files {
+		if newest == nil || fileTimestamp(file.Name) > fileTimestamp(newest.Name) {
+			newest = file
+		}
+	}
+	return newest
+}
+
+// helper function to count distinct timestamps of the files
+func numTimestamps(files []*FileRecord) int {
+	timestamps := map[float64]bool{}
+	for _, file := range files {
+		timestamps[fileTimestamp(file.Name)] = true
+	}
+	return len(timestamps)
+}
+
+// newAuditEvent creates an audit event for a finding under the hash dir
+func (h *Hash) newAuditEvent(path string, finding string, detail string) input.Event {
+	audit := swift.ObjectAudit{
+		Partition: h.Partition.ToSwiftPartition(),
+		Hash:      h.Name,
+		Suffix:    h.Suffix.Name,
+		Path:      path,
+		Finding:   finding,
+		Detail:    detail,
+		AuditedAt: time.Now(),
+	}
+	return input.NewObjectAuditEvent(audit)
+}
+
+// auditDatafile checks the metadata of a datafile against the file on disk
+func (h *Hash) auditDatafile(file *FileRecord) []input.Event {
+	var events []input.Event
+
+	dfile := h.datafile(file)
+	if dfile.MetadataErr == ErrMetadataMissing {
+		events = append(events, h.newAuditEvent(file.Path, AuditMissingMetadata, ""))
+		return events
+	} else if dfile.MetadataErr != nil {
+		events = append(events, h.newAuditEvent(file.Path, AuditCorruptMetadata,
+			dfile.MetadataErr.Error()))
+		return events
+	}
+
+	// zero byte datafile is only valid for an empty object, whatever the
+	// policy and even without Content-Length to compare against
+	v, ok := dfile.Metadata["Content-Length"]
+	if file.Size == 0 && v != "0" {
+		detail := "no Content-Length"
+		if ok {
+			detail = fmt.Sprintf("Content-Length %s", v)
+		}
+		events = append(events, h.newAuditEvent(file.Path, AuditZeroByteDatafile, detail))
+		return events
+	}
+
+	// fragment archive size does not match the object Content-Length
+	if h.isEC() || !ok {
+		return events
+	}
+
+	length, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		events = append(events, h.newAuditEvent(file.Path, AuditCorruptMetadata,
+			fmt.Sprintf("invalid Content-Length: %s", v)))
+		return events
+	}
+
+	if file.Size != length {
+		events = append(events, h.newAuditEvent(file.Path, AuditContentLengthMismatch,
+			fmt.Sprintf("Content-Length %d, size on disk %d", length, file.Size)))
+	}
+
+	return events
+}
+
+// audit classifies files under the hash dir and emits an event per finding
+func (h *Hash) audit() {
+	var datas, tombstones, metas []*FileRecord
+	for _, file := range h.files {
+		switch filepath.Ext(file.Name) {
+		case ".data":
+			datas = append(datas, file)
+		case ".ts":
+			tombstones = append(tombstones, file)
+		case ".meta":
+			metas = append(metas, file)
+		}
+	}
+
+	var events []input.Event
+
+	// several fragment indexes of the same timestamp can be held for EC
+	if n := numTimestamps(datas); n > 1 {
+		events = append(events, h.newAuditEvent(h.Path, AuditMultipleDatafiles,
+			fmt.Sprintf("%d datafiles", n)))
+	}
+
+	if len(datas) == 0 && len(metas) > 0 {
+		events = append(events, h.newAuditEvent(newestFile(metas).Path, AuditOrphanedMeta, ""))
+	}
+
+	if len(datas) > 0 && len(tombstones) > 0 {
+		data := newestFile(datas)
+		ts := newestFile(tombstones)
+		if fileTimestamp(ts.Name) > fileTimestamp(data.Name) {
+			events = append(events, h.newAuditEvent(data.Path, AuditDataWithNewerTombstone,
+				fmt.Sprintf("tombstone %s", ts.Name)))
+		}
+	}
+
+	for _, data := range datas {
+		events = append(events, h.auditDatafile(data)...)
+	}
+
+	for _, event := range events {
+		if !h.publish(event) {
+			return
+		}
+	}
+
+	logp.Debug("hash", "Audit finished for %s with %d findings", h.Path, len(events))
+}
@@ -0,0 +1,115 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAudit(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	meta := func(length string) map[string]string {
+		metadata := map[string]string{"name": "/a/c/o", "X-Timestamp": "1488413430.12345"}
+		if length != "" {
+			metadata["Content-Length"] = length
+		}
+		return metadata
+	}
+
+	tests := []struct {
+		name     string
+		files    map[string]int
+		metadata map[string]string
+		findings []string
+	}{
+		{
+			name:     "/a/c/healthy",
+			files:    map[string]int{"1488413430.12345.data": 5},
+			metadata: meta("5"),
+		},
+		{
+			name:     "/a/c/empty",
+			files:    map[string]int{"1488413430.12345.data": 0},
+			metadata: meta("0"),
+		},
+		{
+			name:     "/a/c/zero-byte",
+			files:    map[string]int{"1488413430.12345.data": 0},
+			metadata: meta("5"),
+			findings: []string{AuditZeroByteDatafile},
+		},
+		{
+			name:     "/a/c/zero-byte-without-length",
+			files:    map[string]int{"1488413430.12345.data": 0},
+			metadata: meta(""),
+			findings: []string{AuditZeroByteDatafile},
+		},
+		{
+			name:     "/a/c/truncated",
+			files:    map[string]int{"1488413430.12345.data": 3},
+			metadata: meta("5"),
+			findings: []string{AuditContentLengthMismatch},
+		},
+		{
+			name:     "/a/c/invalid-length",
+			files:    map[string]int{"1488413430.12345.data": 3},
+			metadata: meta("three"),
+			findings: []string{AuditCorruptMetadata},
+		},
+		{
+			name:     "/a/c/no-metadata",
+			files:    map[string]int{"1488413430.12345.data": 3},
+			findings: []string{AuditMissingMetadata},
+		},
+		{
+			name:     "/a/c/orphaned-meta",
+			files:    map[string]int{"1488413431.00000.meta": 0},
+			findings: []string{AuditOrphanedMeta},
+		},
+		{
+			name:     "/a/c/deleted",
+			files:    map[string]int{"1488413430.12345.data": 5, "1488413431.00000.ts": 0},
+			metadata: meta("5"),
+			findings: []string{AuditDataWithNewerTombstone},
+		},
+		{
+			name:     "/a/c/overwritten",
+			files:    map[string]int{"1488413430.12345.data": 5, "1488413431.00000.data": 5},
+			metadata: meta("5"),
+			findings: []string{AuditMultipleDatafiles},
+		},
+	}
+
+	paths := map[string]string{}
+	for _, test := range tests {
+		dir := node.hashDir(t, "objects", test.name)
+		paths[filepath.Base(dir)] = test.name
+		for name, size := range test.files {
+			path := filepath.Join(dir, name)
+			writeFile(t, path, size)
+			if test.metadata != nil && filepath.Ext(name) == ".data" {
+				writeMetadata(t, path, test.metadata, 0)
+			}
+		}
+	}
+
+	events := node.scan(t, map[string]interface{}{
+		"partition_index_only": false,
+		"enable_audit":         true,
+	}, nil)
+
+	findings := map[string][]string{}
+	for _, audit := range eventsOf(events, "object_audit") {
+		name := paths[audit["hash"].(string)]
+		findings[name] = append(findings[name], audit["finding"].(string))
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.findings, findings[test.name], test.name)
+	}
+}
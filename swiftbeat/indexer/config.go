@@ -1,5 +1,12 @@
 package indexer
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
 var (
 	defaultConfig = indexerConfig{
 		EnableObjectPartitionIndex: true,
@@ -7,13 +14,210 @@ var (
 		EnableAccountIndex:         true,
 		EnableContainerIndex:       true,
 		PartitionIndexOnly:         true,
+		EnableHashesIndex:          false,
+		EnableAudit:                false,
+		EnablePlacementCheck:       false,
+		EnableContainerDBStats:     false,
+		ContainerDBStatsMaxSize:    1024 * 1024 * 1024,
+		PartitionWorkers:           1,
+		MaxIOPS:                    0,
+		EnableIncrementalScan:      false,
+		ScanSummaryInterval:        0,
+		NotInRing:                  "index",
+		ReplicaAuditSource:         "",
+		ReplicaAuditTimeout:        10 * time.Second,
+		EnableExpiredCheck:         false,
+		EnableQuarantineIndex:      false,
+		EnableAsyncPendingIndex:    false,
+		EnableTmpIndex:             false,
+		TmpStaleAge:                24 * time.Hour,
 	}
 )
 
 type indexerConfig struct {
-	EnableObjectPartitionIndex bool `config:"enable_object_partition_index"`
-	EnableDatafileIndex        bool `config:"enable_datafile_index"`
-	EnableAccountIndex         bool `config:"enable_account_index"`
-	EnableContainerIndex       bool `config:"enable_container_index"`
-	PartitionIndexOnly         bool `config:"partition_index_only"`
+	EnableObjectPartitionIndex bool  `config:"enable_object_partition_index"`
+	EnableDatafileIndex        bool  `config:"enable_datafile_index"`
+	EnableAccountIndex         bool  `config:"enable_account_index"`
+	EnableContainerIndex       bool  `config:"enable_container_index"`
+	PartitionIndexOnly         bool  `config:"partition_index_only"`
+	EnableHashesIndex          bool  `config:"enable_hashes_index"`
+	EnableAudit                bool  `config:"enable_audit"`
+	EnablePlacementCheck       bool  `config:"enable_placement_check"`
+	EnableContainerDBStats     bool  `config:"enable_container_db_stats"`
+	ContainerDBStatsMaxSize    int64 `config:"container_db_stats_max_size" validate:"min=0"`
+	EnableIncrementalScan      bool  `config:"enable_incremental_scan"`
+	EnableExpiredCheck         bool  `config:"enable_expired_check"`
+
+	// how to find the device in the ring, local IPs are used if bind_ip is
+	// not set and any port matches if bind_port is not set
+	BindIp    string `config:"bind_ip"`
+	BindPort  int    `config:"bind_port" validate:"min=0"`
+	NotInRing string `config:"not_in_ring"`
+
+	// compares suffix hashes of primary partitions against the peer devices,
+	// fetched with REPLICATE requests or read from the devices dir with the
+	// local source
+	EnableReplicaAudit  bool          `config:"enable_replica_audit"`
+	ReplicaAuditSource  string        `config:"replica_audit_source"`
+	ReplicaAuditTimeout time.Duration `config:"replica_audit_timeout" validate:"min=0"`
+	ReplicaAuditDevices string        `config:"replica_audit_devices"`
+
+	// per disk concurrency and IO throttling, not overridable per resource type
+	PartitionWorkers int `config:"partition_workers" validate:"min=1"`
+	MaxIOPS          int `config:"max_iops" validate:"min=0"`
+	// in-progress scan summaries are reported if set
+	ScanSummaryInterval time.Duration `config:"scan_summary_interval" validate:"min=0"`
+
+	// swift housekeeping dirs of the disk, files in tmp older than
+	// tmp_stale_age are reported as stale
+	EnableQuarantineIndex   bool          `config:"enable_quarantine_index"`
+	EnableAsyncPendingIndex bool          `config:"enable_async_pending_index"`
+	EnableTmpIndex          bool          `config:"enable_tmp_index"`
+	TmpStaleAge             time.Duration `config:"tmp_stale_age" validate:"min=0"`
+
+	// restricts the scan to the listed resources, matched by type (object)
+	// or dir name (objects-1), and partitions. Everything is scanned if unset
+	Resources  []string `config:"resources"`
+	Partitions []int64  `config:"partitions"`
+
+	// per resource type overrides on top of the settings above
+	Account   *common.Config `config:"account"`
+	Container *common.Config `config:"container"`
+	Object    *common.Config `config:"object"`
+}
+
+func (config *indexerConfig) Validate() error {
+
+	for _, resType := range []string{"account", "container", "object"} {
+		resConfig, err := config.resolve(resType)
+		if err != nil {
+			return fmt.Errorf("invalid %s indexer config: %v", resType, err)
+		}
+
+		if resConfig.EnableDatafileIndex && resConfig.PartitionIndexOnly {
+			return fmt.Errorf("enable_datafile_index requires partition_index_only to be disabled for %s", resType)
+		}
+
+		if resConfig.EnableAudit && resConfig.PartitionIndexOnly {
+			return fmt.Errorf("enable_audit requires partition_index_only to be disabled for %s", resType)
+		}
+
+		if resConfig.EnablePlacementCheck && resConfig.PartitionIndexOnly {
+			return fmt.Errorf("enable_placement_check requires partition_index_only to be disabled for %s", resType)
+		}
+
+		if resConfig.EnableExpiredCheck && resConfig.PartitionIndexOnly {
+			return fmt.Errorf("enable_expired_check requires partition_index_only to be disabled for %s", resType)
+		}
+
+		// objects expire without any change to the dirs
+		if resConfig.EnableExpiredCheck && resConfig.EnableIncrementalScan {
+			return fmt.Errorf("enable_expired_check conflicts with enable_incremental_scan for %s", resType)
+		}
+
+		if resConfig.EnableReplicaAudit && !resConfig.EnableHashesIndex {
+			return fmt.Errorf("enable_replica_audit requires enable_hashes_index for %s", resType)
+		}
+
+		// REPLICATE alters the peer disks, so the source is never implied
+		switch resConfig.ReplicaAuditSource {
+		case "":
+			if resConfig.EnableReplicaAudit {
+				return fmt.Errorf("enable_replica_audit requires replica_audit_source to be set to local or replicate for %s", resType)
+			}
+		case "replicate":
+		case "local":
+			if resConfig.ReplicaAuditDevices == "" {
+				return fmt.Errorf("replica_audit_devices is required by the local replica audit source for %s", resType)
+			}
+		default:
+			return fmt.Errorf("invalid replica_audit_source value for %s: %s", resType, resConfig.ReplicaAuditSource)
+		}
+
+		if resConfig.NotInRing != "skip" && resConfig.NotInRing != "index" {
+			return fmt.Errorf("invalid not_in_ring value for %s: %s", resType, resConfig.NotInRing)
+		}
+	}
+
+	return nil
+}
+
+// ValidateConfig checks the indexer settings of a prospector up front, they
+// are unpacked again for every disk by NewDisk
+func ValidateConfig(cfg *common.Config) error {
+	if cfg == nil {
+		return nil
+	}
+	config := defaultConfig
+	return cfg.Unpack(&config)
+}
+
+// resolve returns the effective config for the given resource type, with the
+// per resource type override applied on top of the global settings
+func (config *indexerConfig) resolve(resType string) (indexerConfig, error) {
+	resConfig := *config
+	resConfig.Account = nil
+	resConfig.Container = nil
+	resConfig.Object = nil
+
+	var override *common.Config
+	switch resType {
+	case "account":
+		override = config.Account
+	case "container":
+		override = config.Container
+	case "object":
+		override = config.Object
+	}
+
+	// object only settings are meaningless for db resources
+	if resType != "object" {
+		resConfig.EnableObjectPartitionIndex = false
+		resConfig.EnableDatafileIndex = false
+		resConfig.EnableHashesIndex = false
+		resConfig.EnableAudit = false
+		resConfig.EnablePlacementCheck = false
+		resConfig.EnableIncrementalScan = false
+		resConfig.EnableReplicaAudit = false
+		resConfig.EnableExpiredCheck = false
+	}
+
+	// container only settings
+	if resType != "container" {
+		resConfig.EnableContainerDBStats = false
+	}
+
+	if override != nil {
+		if err := override.Unpack(&resConfig); err != nil {
+			return resConfig, err
+		}
+	}
+
+	return resConfig, nil
+}
+
+// scanResource tells whether the resource is selected for scan
+func (config *indexerConfig) scanResource(name, resType string) bool {
+	if len(config.Resources) == 0 {
+		return true
+	}
+	for _, res := range config.Resources {
+		if res == name || res == resType {
+			return true
+		}
+	}
+	return false
+}
+
+// scanPartition tells whether the partition is selected for scan
+func (config *indexerConfig) scanPartition(partId int64) bool {
+	if len(config.Partitions) == 0 {
+		return true
+	}
+	for _, id := range config.Partitions {
+		if id == partId {
+			return true
+		}
+	}
+	return false
 }
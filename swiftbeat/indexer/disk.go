@@ -1,8 +1,12 @@
 package indexer
 
 import (
-	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/swiftbeat/input"
 	"github.com/elastic/beats/swiftbeat/input/swift"
@@ -12,11 +16,43 @@ import (
 type Disk struct {
 	*IndexRecord
 	config     indexerConfig
+	swiftConf  SwiftConfig
+	swift      *swiftInfo
 	eventChan  chan input.Event
 	done       chan struct{}
 	accounts   *Resource
 	containers *Resource
-	objects    *Resource
+	objects    []*Resource
+	wg         sync.WaitGroup
+	rings      map[string]*ringState
+	ringsLock  sync.Mutex
+	// resources the device was not found in the ring of by the last scan
+	notInRing map[string]bool
+	// handoff partitions of the previous scan per resource
+	handoffs     map[string]*handoffState
+	handoffsLock sync.Mutex
+	// limits partition indexers of the disk and of all disks respectively
+	sem       Semaphore
+	globalSem Semaphore
+	limiter   *RateLimiter
+	// partition states tracked by the prospector for incremental scan
+	states      *input.States
+	rescanOlder time.Duration
+	// partitions listed by the last complete scan per resource
+	partLists     map[string]PartitionList
+	partListsLock sync.Mutex
+	// swift housekeeping dirs found on the disk
+	quarantined  string
+	asyncPending []string
+	tmp          string
+}
+
+// PartitionList holds partition ids of a resource listed by its last
+// complete scan
+type PartitionList struct {
+	ResourceType string
+	PolicyIndex  int64
+	PartIds      []int64
 }
 
 // NewDisk returns a new Disk object.
@@ -25,8 +61,13 @@ type Disk struct {
 func NewDisk(
 	name string,
 	path string,
+	swiftConf SwiftConfig,
+	cfg *common.Config,
 	eventChan chan input.Event,
 	done chan struct{},
+	globalSem Semaphore,
+	states *input.States,
+	rescanOlder time.Duration,
 ) (*Disk, error) {
 	disk := &Disk{
 		IndexRecord: &IndexRecord{
@@ -34,9 +75,27 @@ func NewDisk(
 			Path: path,
 		},
 		config:    defaultConfig,
+		swiftConf: swiftConf,
 		eventChan: eventChan,
+		rings:     map[string]*ringState{},
+		notInRing: map[string]bool{},
+		handoffs:  map[string]*handoffState{},
+		partLists: map[string]PartitionList{},
 		done:      done,
+		globalSem: globalSem,
+		states:    states,
 	}
+	disk.rescanOlder = rescanOlder
+
+	if cfg != nil {
+		if err := cfg.Unpack(&disk.config); err != nil {
+			return nil, err
+		}
+	}
+
+	disk.sem = NewSemaphore(disk.config.PartitionWorkers)
+	disk.limiter = NewRateLimiter(disk.config.MaxIOPS, done)
+
 	return disk, nil
 }
 
@@ -45,27 +104,56 @@ func (d *Disk) init() error {
 	logp.Debug("indexer", "Init disk: %s", path)
 
 	// list disk files
-	files, err := ioutil.ReadDir(path)
+	files, err := readDir(d.limiter, path)
 	if err != nil {
 		logp.Err("list dir(%s) failed: %v", path, err)
 		return err
 	}
 
-	// init account, container and objects respectively
+	// hash prefix / suffix and storage policies are shared by all resources
+	swift, err := loadSwiftInfo(d.swiftConf)
+	if err != nil {
+		logp.Err("load swift conf(%s) failed: %v", d.swiftConf.swiftConf(), err)
+		return err
+	}
+	d.swift = swift
+	d.accounts = nil
+	d.containers = nil
+	d.objects = nil
+	d.quarantined = ""
+	d.asyncPending = nil
+	d.tmp = ""
+
+	// init account, container and objects (one per policy) respectively
 	for _, file := range files {
 		if !file.IsDir() {
 			continue
 		}
 
-		res, _ := NewResource(d, file)
+		name := file.Name()
+		resType, _, _ := parseResourceName(name)
+		if !d.config.scanResource(name, resType) {
+			continue
+		}
 
-		switch file.Name() {
-		case "accounts":
-			d.accounts = res
-		case "containers":
-			d.containers = res
-		case "objects":
-			d.objects = res
+		switch {
+		case name == "accounts":
+			d.accounts, _ = NewResource(d, file)
+		case name == "containers":
+			d.containers, _ = NewResource(d, file)
+		case name == "objects" || strings.HasPrefix(name, "objects-"):
+			res, err := NewResource(d, file)
+			if err != nil {
+				logp.Warn("skip object dir(%s): %v", name, err)
+				continue
+			}
+			d.objects = append(d.objects, res)
+		case name == "quarantined":
+			d.quarantined = filepath.Join(path, name)
+		case name == "async_pending" || strings.HasPrefix(name, "async_pending-"):
+			d.asyncPending = append(d.asyncPending, filepath.Join(path, name))
+		case name == "tmp":
+			d.tmp = filepath.Join(path, name)
 		}
 	}
 	return nil
@@ -80,15 +168,130 @@ func (d *Disk) BuildIndex() {
 		return
 	}
 
-	if d.config.EnableAccountIndex && d.accounts != nil {
-		go d.accounts.BuildIndex()
+	var resources []*Resource
+	if d.accounts != nil && d.accounts.config.EnableAccountIndex {
+		resources = append(resources, d.accounts)
+	}
+	if d.containers != nil && d.containers.config.EnableContainerIndex {
+		resources = append(resources, d.containers)
+	}
+	resources = append(resources, d.objects...)
+
+	for _, res := range resources {
+		if d.stopped() {
+			break
+		}
+
+		d.wg.Add(1)
+		go func(r *Resource) {
+			defer d.wg.Done()
+			r.BuildIndex()
+			r.Wait()
+		}(res)
+	}
+
+	// housekeeping dirs are scanned along with the resources
+	var jobs []func()
+	if d.quarantined != "" && d.config.EnableQuarantineIndex {
+		jobs = append(jobs, d.indexQuarantined)
+	}
+	if d.config.EnableAsyncPendingIndex {
+		for _, path := range d.asyncPending {
+			path := path
+			jobs = append(jobs, func() { d.indexAsyncPending(path) })
+		}
+	}
+	if d.tmp != "" && d.config.EnableTmpIndex {
+		jobs = append(jobs, d.indexTmp)
+	}
+
+	for _, job := range jobs {
+		if d.stopped() {
+			break
+		}
+
+		d.wg.Add(1)
+		go func(job func()) {
+			defer d.wg.Done()
+			d.acquire()
+			defer d.release()
+			job()
+		}(job)
+	}
+}
+
+// stopped returns true once the scan is cancelled on shutdown
+func (d *Disk) stopped() bool {
+	select {
+	case <-d.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// publish sends the event to the prospector unless the scan is cancelled
+// It returns false if the event is dropped due to cancellation
+func (d *Disk) publish(event input.Event) bool {
+	select {
+	case <-d.done:
+		return false
+	case d.eventChan <- event:
+		return true
+	}
+}
+
+// acquire blocks until a partition indexer slot is available on both the
+// disk and the global level
+func (d *Disk) acquire() {
+	d.sem.acquire()
+	d.globalSem.acquire()
+}
+
+func (d *Disk) release() {
+	d.globalSem.release()
+	d.sem.release()
+}
+
+// recordPartitions keeps the partition list of the resource once its scan
+// completes
+func (d *Disk) recordPartitions(r *Resource) {
+	// lists of a scan restricted to some partitions are partial
+	if len(d.config.Partitions) > 0 {
+		return
+	}
+
+	list := PartitionList{
+		ResourceType: r.Type,
+		PolicyIndex:  r.PolicyIndex,
 	}
-	if d.config.EnableContainerIndex && d.containers != nil {
-		go d.containers.BuildIndex()
+	for _, part := range r.partitions {
+		if part.PartId >= 0 {
+			list.PartIds = append(list.PartIds, part.PartId)
+		}
 	}
-	if d.objects != nil {
-		go d.objects.BuildIndex()
+
+	d.partListsLock.Lock()
+	d.partLists[ringKey(r.Type, r.PolicyIndex)] = list
+	d.partListsLock.Unlock()
+}
+
+// PartitionLists returns partition lists of all resources completely scanned
+func (d *Disk) PartitionLists() []PartitionList {
+	d.partListsLock.Lock()
+	defer d.partListsLock.Unlock()
+
+	var lists []PartitionList
+	for _, list := range d.partLists {
+		lists = append(lists, list)
 	}
+	return lists
+}
+
+// Wait blocks until index build started by BuildIndex finishes on all
+// resources of the disk
+func (d *Disk) Wait() {
+	d.wg.Wait()
 }
 
 func (d *Disk) GetEvents() <-chan input.Event {
@@ -97,5 +300,5 @@ func (d *Disk) GetEvents() <-chan input.Event {
 
 // AnnotateSwiftObject add info from indexer to the swift.Object data object
 func (d *Disk) AnnotateSwiftObject(obj *swift.Object) {
-	obj.Annotate(*d)
+	obj.Annotate(d)
 }
@@ -0,0 +1,87 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+// buildIndex runs BuildIndex and Wait and fails the test if the scan does not
+// return in time
+func buildIndex(t *testing.T, disk *Disk) {
+	finished := make(chan struct{})
+	go func() {
+		disk.BuildIndex()
+		disk.Wait()
+		close(finished)
+	}()
+
+	select {
+	case <-finished:
+	case <-time.After(5 * time.Second):
+		t.Fatal("scan not finished after cancellation")
+	}
+}
+
+func TestScanCancelled(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	for _, part := range []string{"0", "1", "2", "3"} {
+		node.mkdir(t, "objects", part)
+		node.mkdir(t, "containers", part)
+	}
+
+	// nothing is published by a scan cancelled before it starts
+	eventChan := make(chan input.Event, 100)
+	done := make(chan struct{})
+	disk := node.newDisk(t, nil, nil, eventChan, done)
+	close(done)
+	buildIndex(t, disk)
+	assert.Len(t, eventChan, 0)
+	assert.Len(t, disk.PartitionLists(), 0)
+
+	// scan blocked on publishing with nobody reading returns once cancelled
+	eventChan = make(chan input.Event)
+	done = make(chan struct{})
+	disk = node.newDisk(t, nil, nil, eventChan, done)
+	go func() {
+		<-eventChan
+		close(done)
+	}()
+	buildIndex(t, disk)
+
+	// partial partition lists of the cancelled scan are not recorded
+	assert.Len(t, disk.PartitionLists(), 0)
+}
+
+func TestScanPartitionLists(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	for _, part := range []string{"0", "1", "2", "3"} {
+		node.mkdir(t, "objects", part)
+	}
+
+	eventChan := make(chan input.Event, 100)
+	disk := node.newDisk(t, nil, nil, eventChan, make(chan struct{}))
+	buildIndex(t, disk)
+
+	lists := disk.PartitionLists()
+	if assert.Len(t, lists, 1) {
+		assert.Equal(t, "object", lists[0].ResourceType)
+		assert.Equal(t, int64(0), lists[0].PolicyIndex)
+		// partitions are listed in scan order
+		parts := map[int64]bool{}
+		for _, partId := range lists[0].PartIds {
+			parts[partId] = true
+		}
+		assert.Equal(t, map[int64]bool{0: true, 1: true, 2: true, 3: true}, parts)
+	}
+}
@@ -0,0 +1,109 @@
+package indexer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const (
+	ecPolicyType = "erasure_coding"
+)
+
+// fragment archive of an erasure coded object
+type fragment struct {
+	file      *FileRecord
+	timestamp string
+	index     int64
+	durable   bool
+}
+
+// parseFragName splits the .data file name of a fragment archive, which is
+// <timestamp>#<frag_index>.data, or <timestamp>#<frag_index>#d.data once
+// durable. Durable state of the former is marked by a <timestamp>.durable file
+func parseFragName(name string) (string, int64, bool, error) {
+	parts := strings.Split(strings.TrimSuffix(name, ".data"), "#")
+	if len(parts) < 2 || len(parts) > 3 {
+		return "", -1, false, fmt.Errorf("invalid fragment archive name: %s", name)
+	}
+
+	index, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || index < 0 {
+		return "", -1, false, fmt.Errorf("invalid fragment index in name: %s", name)
+	}
+
+	durable := len(parts) == 3 && parts[2] == "d"
+	return parts[0], index, durable, nil
+}
+
+// isEC tells whether the resource is stored with an erasure coding policy
+func (r *Resource) isEC() bool {
+	return r.PolicyType == ecPolicyType
+}
+
+// fragments returns the fragment archives found in the hash dir, one node
+// might hold several fragment indexes of the same object
+func (h *Hash) fragments() []fragment {
+	durables := map[string]bool{}
+	for _, file := range h.files {
+		if filepath.Ext(file.Name) == ".durable" {
+			durables[strings.TrimSuffix(file.Name, ".durable")] = true
+		}
+	}
+
+	var frags []fragment
+	for _, file := range h.files {
+		if filepath.Ext(file.Name) != ".data" {
+			continue
+		}
+
+		timestamp, index, durable, err := parseFragName(file.Name)
+		if err != nil {
+			logp.Debug("hash", "skip file(%s): %v", file.Path, err)
+			continue
+		}
+
+		frags = append(frags, fragment{
+			file:      file,
+			timestamp: timestamp,
+			index:     index,
+			durable:   durable || durables[timestamp],
+		})
+	}
+	return frags
+}
+
+// newestFragments returns the fragment archives of the newest timestamp, older
+// ones are left over from overwrites until the reconstructor reclaims them
+func newestFragments(frags []fragment) []fragment {
+	var newest []fragment
+	for _, frag := range frags {
+		if len(newest) > 0 {
+			ts, cur := fileTimestamp(frag.file.Name), fileTimestamp(newest[0].file.Name)
+			if ts < cur {
+				continue
+			}
+			if ts > cur {
+				newest = newest[:0]
+			}
+		}
+		newest = append(newest, frag)
+	}
+	return newest
+}
+
+// expectedFragIndex returns the fragment index the device is supposed to hold
+// for the partition according to its position in the ring, or -1 on handoff
+func (p *Partition) expectedFragIndex() int64 {
+	if p.Handoff || p.ReplicaId < 0 {
+		return -1
+	}
+	// with ec_duplication_factor, fragments are repeated across nodes
+	if p.Resource.ecFragments > 0 {
+		return p.ReplicaId % p.Resource.ecFragments
+	}
+	return p.ReplicaId
+}
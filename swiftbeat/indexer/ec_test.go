@@ -0,0 +1,79 @@
+// +build !integration
+
+package indexer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFragName(t *testing.T) {
+	tests := []struct {
+		name    string
+		ts      string
+		index   int64
+		durable bool
+		err     bool
+	}{
+		{name: "1488413430.12345#2.data", ts: "1488413430.12345", index: 2},
+		{name: "1488413430.12345#0#d.data", ts: "1488413430.12345", index: 0, durable: true},
+		{name: "1488413430.12345#11#x.data", ts: "1488413430.12345", index: 11},
+		{name: "1488413430.12345.data", index: -1, err: true},
+		{name: "1488413430.12345#a.data", index: -1, err: true},
+		{name: "1488413430.12345#-1.data", index: -1, err: true},
+		{name: "1488413430.12345#1#d#x.data", index: -1, err: true},
+	}
+
+	for _, test := range tests {
+		ts, index, durable, err := parseFragName(test.name)
+		if test.err {
+			assert.Error(t, err, test.name)
+		} else {
+			assert.NoError(t, err, test.name)
+		}
+		assert.Equal(t, test.ts, ts, test.name)
+		assert.Equal(t, test.index, index, test.name)
+		assert.Equal(t, test.durable, durable, test.name)
+	}
+}
+
+func TestNewestFragments(t *testing.T) {
+	frags := func(names ...string) []fragment {
+		var frags []fragment
+		for _, name := range names {
+			frags = append(frags, fragment{file: &FileRecord{IndexRecord: &IndexRecord{Name: name}}})
+		}
+		return frags
+	}
+	names := func(frags []fragment) []string {
+		var names []string
+		for _, frag := range frags {
+			names = append(names, frag.file.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		files  []string
+		newest []string
+	}{
+		{},
+		{
+			files:  []string{"1488413430.12345#2.data"},
+			newest: []string{"1488413430.12345#2.data"},
+		},
+		{
+			files:  []string{"1488413430.12345#2.data", "1488413431.00000#2#d.data"},
+			newest: []string{"1488413431.00000#2#d.data"},
+		},
+		{
+			files:  []string{"1488413431.00000#1.data", "1488413430.12345#2.data", "1488413431.00000#4.data"},
+			newest: []string{"1488413431.00000#1.data", "1488413431.00000#4.data"},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.newest, names(newestFragments(frags(test.files...))), "%v", test.files)
+	}
+}
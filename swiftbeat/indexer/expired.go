@@ -0,0 +1,83 @@
+package indexer
+
+import (
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+const (
+	deleteAtKey = "X-Delete-At"
+)
+
+// helper function to parse X-Delete-At of the object metadata, zero time is
+// returned if not set
+func deleteAt(metadata map[string]string) time.Time {
+	v, ok := metadata[deleteAtKey]
+	if !ok {
+		return time.Time{}
+	}
+
+	ts, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(int64(ts), 0)
+}
+
+// helper function to tell whether the object is past its X-Delete-At
+func isExpired(metadata map[string]string, now time.Time) bool {
+	at := deleteAt(metadata)
+	return !at.IsZero() && !at.After(now)
+}
+
+// checkExpired counts the object of the hash dir on the partition if it is
+// past its X-Delete-At but not reaped by the object expirer yet
+func (h *Hash) checkExpired() {
+	var datas, tombstones, metas []*FileRecord
+	for _, file := range h.files {
+		switch filepath.Ext(file.Name) {
+		case ".data":
+			datas = append(datas, file)
+		case ".ts":
+			tombstones = append(tombstones, file)
+		case ".meta":
+			metas = append(metas, file)
+		}
+	}
+
+	data := newestFile(datas)
+	if data == nil {
+		return
+	}
+	// object deleted already, waiting for reclaim
+	if ts := newestFile(tombstones); ts != nil && fileTimestamp(ts.Name) > fileTimestamp(data.Name) {
+		return
+	}
+
+	dfile := h.datafile(data)
+	if dfile.MetadataErr != nil {
+		return
+	}
+	metadata := dfile.Metadata
+
+	// X-Delete-At set or removed by a later POST is kept in .meta
+	if meta := newestFile(metas); meta != nil && fileTimestamp(meta.Name) > fileTimestamp(data.Name) {
+		mfile := h.datafile(meta)
+		if mfile.MetadataErr == nil {
+			metadata = mfile.Metadata
+		}
+	}
+
+	if !isExpired(metadata, time.Now()) {
+		return
+	}
+
+	// all fragment archives held for an EC object are counted
+	h.Partition.NumExpired += 1
+	for _, file := range datas {
+		if fileTimestamp(file.Name) == fileTimestamp(data.Name) {
+			h.Partition.ExpiredBytes += file.Size
+		}
+	}
+}
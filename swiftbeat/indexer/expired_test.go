@@ -0,0 +1,148 @@
+// +build !integration
+
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+func TestIsExpired(t *testing.T) {
+	now := time.Unix(1488413430, 0)
+
+	tests := []struct {
+		name     string
+		deleteAt string
+		expired  bool
+	}{
+		{name: "not expiring"},
+		{name: "expiring later", deleteAt: "1488413431"},
+		{name: "expiring now", deleteAt: "1488413430", expired: true},
+		{name: "expired", deleteAt: "1488413000", expired: true},
+		{name: "expired with fraction", deleteAt: "1488413000.5", expired: true},
+		{name: "invalid", deleteAt: "soon"},
+	}
+
+	for _, test := range tests {
+		metadata := map[string]string{}
+		if test.deleteAt != "" {
+			metadata[deleteAtKey] = test.deleteAt
+		}
+		assert.Equal(t, test.expired, isExpired(metadata, now), test.name)
+	}
+}
+
+func TestCheckExpired(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	past := fmt.Sprint(time.Now().Add(-time.Hour).Unix())
+	future := fmt.Sprint(time.Now().Add(time.Hour).Unix())
+
+	// writes the files of the object with X-Delete-At set in
+	// the metadata of the datafile, the .meta file if any comes with expiry
+	// of metaDeleteAt
+	put := func(name string, size int, deleteAt string, files []string, metaDeleteAt string) {
+		dir := node.hashDir(t, "objects", name)
+		for _, file := range append([]string{"1488413430.12345.data"}, files...) {
+			path := filepath.Join(dir, file)
+			metadata := map[string]string{"name": name, "X-Timestamp": "1488413430.12345"}
+			switch filepath.Ext(file) {
+			case ".data":
+				writeFile(t, path, size)
+				if deleteAt != "" {
+					metadata[deleteAtKey] = deleteAt
+				}
+			case ".meta":
+				writeFile(t, path, 0)
+				if metaDeleteAt != "" {
+					metadata[deleteAtKey] = metaDeleteAt
+				}
+			default:
+				writeFile(t, path, 0)
+				continue
+			}
+			writeMetadata(t, path, metadata, 0)
+		}
+	}
+
+	put("/a/c/expired", 100, past, nil, "")
+	put("/a/c/expiring", 10, future, nil, "")
+	put("/a/c/not-expiring", 10, "", nil, "")
+	put("/a/c/reaped", 10, past, []string{"1488413431.00000.ts"}, "")
+	put("/a/c/expiry-removed", 10, past, []string{"1488413431.00000.meta"}, "")
+	put("/a/c/expiry-set", 20, "", []string{"1488413431.00000.meta"}, past)
+
+	tests := []struct {
+		enabled      bool
+		numExpired   int64
+		expiredBytes int64
+	}{
+		{false, -1, -1},
+		{true, 2, 120},
+	}
+
+	for _, test := range tests {
+		events := node.scan(t, map[string]interface{}{
+			"partition_index_only": false,
+			"enable_expired_check": test.enabled,
+		}, nil)
+
+		// expired objects are counted on the partitions they are found in
+		numExpired, expiredBytes := int64(-1), int64(-1)
+		for _, part := range eventsOf(events, "obj_partition") {
+			if _, ok := part["num_expired"]; !ok {
+				continue
+			}
+			if numExpired < 0 {
+				numExpired, expiredBytes = 0, 0
+			}
+			numExpired += part["num_expired"].(int64)
+			expiredBytes += part["expired_bytes"].(int64)
+		}
+		assert.Equal(t, test.numExpired, numExpired)
+		assert.Equal(t, test.expiredBytes, expiredBytes)
+	}
+}
+
+func TestHashDatafileReadOnce(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	dir := node.hashDir(t, "objects", "/a/c/o")
+	path := filepath.Join(dir, "1488413430.12345.data")
+	writeFile(t, path, 0)
+	writeMetadata(t, path, map[string]string{"name": "/a/c/o", deleteAtKey: "1488413000"}, 0)
+
+	disk := node.newDisk(t, nil, nil, make(chan input.Event), make(chan struct{}))
+	res := &Resource{IndexRecord: &IndexRecord{}, Disk: disk, stats: newScanStats()}
+	part := &Partition{IndexRecord: &IndexRecord{}, Resource: res}
+	suffix := &Suffix{IndexRecord: &IndexRecord{}, Partition: part}
+	h := &Hash{IndexRecord: &IndexRecord{Name: filepath.Base(dir), Path: dir}, Suffix: suffix}
+	if !assert.NoError(t, h.init()) || !assert.Len(t, h.files, 1) {
+		return
+	}
+
+	dfile := h.datafile(h.files[0])
+	assert.NoError(t, dfile.MetadataErr)
+	assert.True(t, dfile.Expired)
+
+	// later checks of the hash dir get the metadata read by the first one
+	if err := syscall.Removexattr(path, metadataKey); err != nil {
+		t.Fatal(err)
+	}
+	assert.True(t, dfile == h.datafile(h.files[0]))
+	assert.Equal(t, "/a/c/o", h.datafile(h.files[0]).Metadata["name"])
+
+	// the metadata is really gone from the datafile
+	_, err := readMetadata(nil, path)
+	assert.Equal(t, ErrMetadataMissing, err)
+}
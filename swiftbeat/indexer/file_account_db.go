@@ -3,7 +3,6 @@ package indexer
 import (
 	"database/sql"
 	_ "github.com/mattn/go-sqlite3"
-	"strings"
 	"time"
 
 	"github.com/elastic/beats/libbeat/logp"
@@ -38,6 +37,7 @@ func (f *AccountDBfile) Index() {
 	db, err := sql.Open("sqlite3", f.Path)
 	if err != nil {
 		logp.Err("open sqlite file(%s) failed: %v", f.Path, err)
+		f.incrError(errDB)
 		return
 	}
 	defer db.Close()
@@ -48,6 +48,7 @@ func (f *AccountDBfile) Index() {
                                LIMIT 1`)
 	if err != nil {
 		logp.Err("sql query failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
 		return
 	}
 
@@ -62,6 +63,7 @@ func (f *AccountDBfile) Index() {
 			&container_count, &object_count, &bytes_used)
 		if err != nil {
 			logp.Err("sql rows can failed on file(%s): %v", f.Path, err)
+			f.incrError(errDB)
 			continue
 		}
 
@@ -74,6 +76,7 @@ func (f *AccountDBfile) Index() {
 	err = rows.Err()
 	if err != nil {
 		logp.Err("sql rows iteration failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
 	}
 }
 
@@ -91,8 +94,8 @@ func (f *AccountDBfile) ToSwiftAccount() swift.Account {
 			RingMtime:    f.RingMtime,
 			Handoff:      f.Handoff,
 			ReplicaId:    f.ReplicaId,
-			PeerDevices:  strings.Join(f.PeerDevices, ","),
-			PeerIps:      strings.Join(f.PeerIps, ","),
+			PeerDevices:  f.PeerDevices,
+			PeerIps:      f.PeerIps,
 			RingCKSum:    f.RingCKSum,
 		},
 		Path:           f.Path,
@@ -102,6 +105,7 @@ func (f *AccountDBfile) ToSwiftAccount() swift.Account {
 		ContainerCount: f.container_count,
 		ObjectCount:    f.object_count,
 		BytesUsedMB:    int64(f.bytes_used / 1024 / 1024),
+		BytesUsed:      f.bytes_used,
 	}
 	return a
 }
@@ -2,7 +2,9 @@ package indexer
 
 import (
 	"database/sql"
+	"fmt"
 	_ "github.com/mattn/go-sqlite3"
+	"strconv"
 	"strings"
 	"time"
 
@@ -19,6 +21,27 @@ type ContainerDBfile struct {
 	object_count int64
 	bytes_used   int64
 	policy_index int64
+	pending_size int64
+
+	// deep stats from the object and shard_range tables
+	deepStats     bool
+	object_rows   int64
+	deleted_rows  int64
+	oldest_object time.Time
+	newest_object time.Time
+	shard_ranges  map[string]int64
+}
+
+// shard range states as defined in swift.common.utils.ShardRange
+var shardRangeStates = map[int64]string{
+	10: "found",
+	20: "created",
+	30: "cleaved",
+	40: "active",
+	50: "shrinking",
+	60: "sharding",
+	70: "sharded",
+	80: "shrunk",
 }
 
 // NewContainerDBfile returns a new ContainerDBfile object
@@ -30,6 +53,8 @@ func NewContainerDBfile(
 		object_count: -1,
 		bytes_used:   -1,
 		policy_index: -1,
+		object_rows:  -1,
+		deleted_rows: -1,
 	}
 	return dbfile, nil
 }
@@ -39,6 +64,7 @@ func (f *ContainerDBfile) Index() {
 	db, err := sql.Open("sqlite3", f.Path)
 	if err != nil {
 		logp.Err("open sqlite file(%s) failed: %v", f.Path, err)
+		f.incrError(errDB)
 		return
 	}
 	defer db.Close()
@@ -50,6 +76,7 @@ func (f *ContainerDBfile) Index() {
                                LIMIT 1`)
 	if err != nil {
 		logp.Err("sql query failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
 		return
 	}
 
@@ -65,6 +92,7 @@ func (f *ContainerDBfile) Index() {
 			&object_count, &bytes_used, &policy_index)
 		if err != nil {
 			logp.Err("sql rows can failed on file(%s): %v", f.Path, err)
+			f.incrError(errDB)
 			continue
 		}
 
@@ -78,6 +106,118 @@ func (f *ContainerDBfile) Index() {
 	err = rows.Err()
 	if err != nil {
 		logp.Err("sql rows iteration failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
+	}
+	rows.Close()
+
+	// running query can not be interrupted, skip the rest on shutdown
+	if f.deepStats && !f.stopped() {
+		f.indexObjects(db)
+		f.indexShardRanges(db)
+	}
+}
+
+// helper function to parse swift timestamp stored as text in container db
+func parseTimestamp(ts string) (time.Time, error) {
+	secs, err := strconv.ParseFloat(ts, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(secs*1e9)), nil
+}
+
+// indexObjects counts rows of the object table with deleted/undeleted split
+// and finds the oldest/newest undeleted object
+func (f *ContainerDBfile) indexObjects(db *sql.DB) {
+	rows, err := db.Query(`SELECT deleted, COUNT(*),
+				      MIN(created_at), MAX(created_at)
+			       FROM object
+			       GROUP BY deleted`)
+	if err != nil {
+		logp.Err("sql query failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
+		return
+	}
+	defer rows.Close()
+
+	f.object_rows = 0
+	f.deleted_rows = 0
+	for rows.Next() {
+		var deleted int64
+		var count int64
+		var oldest sql.NullString
+		var newest sql.NullString
+
+		err = rows.Scan(&deleted, &count, &oldest, &newest)
+		if err != nil {
+			logp.Err("sql rows can failed on file(%s): %v", f.Path, err)
+			f.incrError(errDB)
+			continue
+		}
+
+		if deleted != 0 {
+			f.deleted_rows += count
+			continue
+		}
+
+		f.object_rows += count
+		if oldest.Valid {
+			if t, err := parseTimestamp(oldest.String); err == nil {
+				f.oldest_object = t
+			}
+		}
+		if newest.Valid {
+			if t, err := parseTimestamp(newest.String); err == nil {
+				f.newest_object = t
+			}
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		logp.Err("sql rows iteration failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
+	}
+}
+
+// indexShardRanges counts shard ranges by state, the table only exists for
+// dbs created by sharding aware swift releases
+func (f *ContainerDBfile) indexShardRanges(db *sql.DB) {
+	rows, err := db.Query(`SELECT state, COUNT(*)
+			       FROM shard_range
+			       WHERE deleted = 0
+			       GROUP BY state`)
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return
+		}
+		logp.Err("sql query failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
+		return
+	}
+	defer rows.Close()
+
+	f.shard_ranges = make(map[string]int64)
+	for rows.Next() {
+		var state int64
+		var count int64
+
+		err = rows.Scan(&state, &count)
+		if err != nil {
+			logp.Err("sql rows can failed on file(%s): %v", f.Path, err)
+			f.incrError(errDB)
+			continue
+		}
+
+		name, ok := shardRangeStates[state]
+		if !ok {
+			name = fmt.Sprintf("state_%d", state)
+		}
+		f.shard_ranges[name] += count
+	}
+	err = rows.Err()
+	if err != nil {
+		logp.Err("sql rows iteration failed on file(%s): %v", f.Path, err)
+		f.incrError(errDB)
 	}
 }
 
@@ -95,18 +235,25 @@ func (f *ContainerDBfile) ToSwiftContainer() swift.Container {
 			RingMtime:    f.RingMtime,
 			Handoff:      f.Handoff,
 			ReplicaId:    f.ReplicaId,
-			PeerDevices:  strings.Join(f.PeerDevices, ","),
-			PeerIps:      strings.Join(f.PeerIps, ","),
+			PeerDevices:  f.PeerDevices,
+			PeerIps:      f.PeerIps,
 			RingCKSum:    f.RingCKSum,
 		},
-		Path:        f.Path,
-		SizeKB:      int64(f.Size / 1024),
-		Account:     f.account,
-		Container:   f.container,
-		Status:      f.status,
-		ObjectCount: f.object_count,
-		BytesUsedMB: int64(f.bytes_used / 1024 / 1024),
-		PolicyIndex: f.policy_index,
+		Path:         f.Path,
+		SizeKB:       int64(f.Size / 1024),
+		Account:      f.account,
+		Container:    f.container,
+		Status:       f.status,
+		ObjectCount:  f.object_count,
+		BytesUsedMB:  int64(f.bytes_used / 1024 / 1024),
+		BytesUsed:    f.bytes_used,
+		PolicyIndex:  f.policy_index,
+		PendingSize:  f.pending_size,
+		ObjectRows:   f.object_rows,
+		DeletedRows:  f.deleted_rows,
+		OldestObject: f.oldest_object,
+		NewestObject: f.newest_object,
+		ShardRanges:  f.shard_ranges,
 	}
 	return c
 }
@@ -0,0 +1,130 @@
+// +build !integration
+
+package indexer
+
+import (
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+// writeContainerDB creates a container db with the tables the indexer reads
+func writeContainerDB(t *testing.T, path string, sharding bool) {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	stmts := []string{
+		`CREATE TABLE container_info (account TEXT, container TEXT, status TEXT,
+			reported_object_count INTEGER, reported_bytes_used INTEGER,
+			storage_policy_index INTEGER)`,
+		`INSERT INTO container_info VALUES ('AUTH_a', 'c', '', 2, 2048, 1)`,
+		`CREATE TABLE object (name TEXT, created_at TEXT, deleted INTEGER)`,
+		`INSERT INTO object VALUES ('o1', '1488413430.00000', 0)`,
+		`INSERT INTO object VALUES ('o2', '1488413490.00000', 0)`,
+		`INSERT INTO object VALUES ('o3', '1488413400.00000', 1)`,
+	}
+	if sharding {
+		stmts = append(stmts,
+			`CREATE TABLE shard_range (name TEXT, state INTEGER, deleted INTEGER)`,
+			`INSERT INTO shard_range VALUES ('s1', 40, 0)`,
+			`INSERT INTO shard_range VALUES ('s2', 60, 0)`,
+			`INSERT INTO shard_range VALUES ('s3', 60, 0)`,
+			`INSERT INTO shard_range VALUES ('s4', 90, 0)`,
+			`INSERT INTO shard_range VALUES ('s5', 40, 1)`,
+		)
+	}
+	for _, stmt := range stmts {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestContainerDBStats(t *testing.T) {
+	tests := []struct {
+		name        string
+		settings    map[string]interface{}
+		sharding    bool
+		deep        bool
+		shardRanges common.MapStr
+	}{
+		{
+			name: "container info only",
+		},
+		{
+			name:     "deep stats without shard ranges",
+			settings: map[string]interface{}{"enable_container_db_stats": true},
+			deep:     true,
+		},
+		{
+			name:     "deep stats of a sharding db",
+			settings: map[string]interface{}{"enable_container_db_stats": true},
+			sharding: true,
+			deep:     true,
+			shardRanges: common.MapStr{
+				"active":   int64(1),
+				"sharding": int64(2),
+				"state_90": int64(1),
+			},
+		},
+		{
+			name: "deep stats skipped for db over the size limit",
+			settings: map[string]interface{}{
+				"enable_container_db_stats":   true,
+				"container_db_stats_max_size": 1024,
+			},
+			sharding: true,
+		},
+	}
+
+	for _, test := range tests {
+		node := newTestNode(t)
+
+		hash := "3c455f4c36c2927865b8822a4cef8a1f"
+		dir := node.mkdir(t, "containers", "0", hash[len(hash)-3:], hash)
+		writeContainerDB(t, filepath.Join(dir, hash+".db"), test.sharding)
+		writeFile(t, filepath.Join(dir, hash+".db.pending"), 4096)
+
+		settings := map[string]interface{}{"partition_index_only": false}
+		for k, v := range test.settings {
+			settings[k] = v
+		}
+		events := node.scan(t, settings, nil)
+		os.RemoveAll(node.swiftDir)
+
+		dbs := eventsOf(events, "db")
+		if !assert.Len(t, dbs, 1, test.name) {
+			continue
+		}
+		db := dbs[0]
+		assert.Equal(t, "AUTH_a", db["account"], test.name)
+		assert.Equal(t, "c", db["container"], test.name)
+		assert.Equal(t, int64(2), db["object_count"], test.name)
+		assert.Equal(t, int64(1), db["policy_index"], test.name)
+		assert.Equal(t, int64(4), db["pending_size_kb"], test.name)
+
+		if !test.deep {
+			assert.NotContains(t, db, "object_rows", test.name)
+			assert.NotContains(t, db, "shard_ranges", test.name)
+			continue
+		}
+		assert.Equal(t, int64(2), db["object_rows"], test.name)
+		assert.Equal(t, int64(1), db["deleted_rows"], test.name)
+		assert.Equal(t, common.Time(time.Unix(1488413430, 0)), db["oldest_object"], test.name)
+		assert.Equal(t, common.Time(time.Unix(1488413490, 0)), db["newest_object"], test.name)
+		if test.shardRanges == nil {
+			assert.NotContains(t, db, "shard_ranges", test.name)
+		} else {
+			assert.Equal(t, test.shardRanges, db["shard_ranges"], test.name)
+		}
+	}
+}
@@ -2,7 +2,10 @@ package indexer
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"syscall"
+	"time"
 
 	pickle "github.com/hydrogen18/stalecucumber"
 
@@ -11,9 +14,12 @@ import (
 )
 
 const (
+	// large metadata is split by Swift into metadataKey, metadataKey1, ...
 	metadataKey = "user.swift.metadata"
-	// XXX: is 4096 enough for all the cases?
-	xattrBufSize = 4096
+)
+
+var (
+	ErrMetadataMissing = errors.New("metadata xattr missing")
 )
 
 type Datafile struct {
@@ -21,6 +27,14 @@ type Datafile struct {
 	// for simplicity, store both kv in string
 	// and convert if necessary when use
 	Metadata map[string]string
+	// error occurred when reading or unpickling metadata
+	MetadataErr error
+	// fragment archive info of EC policies, FragIndex is -1 otherwise
+	FragIndex     int64
+	Durable       bool
+	FragMisplaced bool
+	// object is past its X-Delete-At
+	Expired bool
 }
 
 // NewDatafile returns a new Datafile object
@@ -30,32 +44,81 @@ func NewDatafile(
 	dfile := &Datafile{
 		FileRecord: f,
 		Metadata:   map[string]string{},
+		FragIndex:  -1,
 	}
 	return dfile, nil
 }
 
+// readXattr reads the whole value of one xattr key with the size probed first
+func readXattr(path string, key string) ([]byte, error) {
+	size, err := syscall.Getxattr(path, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, size)
+	size, err = syscall.Getxattr(path, key, buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf[:size], nil
+}
+
+// readMetadata reads and concatenates all metadata xattr chunks
+// each chunk read counts as one IO against the rate limit
+func readMetadata(limiter *RateLimiter, path string) ([]byte, error) {
+	var data []byte
+	for i := 0; ; i++ {
+		key := metadataKey
+		if i > 0 {
+			key = fmt.Sprintf("%s%d", metadataKey, i)
+		}
+
+		limiter.wait()
+		chunk, err := readXattr(path, key)
+		if err == syscall.ENODATA {
+			if i == 0 {
+				return nil, ErrMetadataMissing
+			}
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		data = append(data, chunk...)
+	}
+	return data, nil
+}
+
 // Index individual datafile to fill in structured data
 func (f *Datafile) Index() {
-	buf := make([]byte, xattrBufSize)
-
 	// read from xattr
-	size, err := syscall.Getxattr(f.Path, metadataKey, buf)
+	data, err := readMetadata(f.limiter, f.Path)
 	if err != nil {
 		logp.Err("read xattr file(%s) failed: %v", f.Path, err)
+		f.MetadataErr = err
+		f.incrError(errMetadata)
 		return
 	}
 
 	// wrapping over native buf with io.Reader interface to unpickle
-	buffer := bytes.NewBuffer(buf[:size])
+	buffer := bytes.NewBuffer(data)
 	dict, err := pickle.Dict(pickle.Unpickle(buffer))
 	if err != nil {
 		logp.Err("unpickling data(%s) failed: %v", buffer, err)
+		f.MetadataErr = err
+		f.incrError(errMetadata)
 		return
 	}
 
 	for key, value := range dict {
-		f.Metadata[key.(string)] = value.(string)
+		k, kok := key.(string)
+		v, vok := value.(string)
+		if !kok || !vok {
+			continue
+		}
+		f.Metadata[k] = v
 	}
+	f.Expired = isExpired(f.Metadata, time.Now())
 }
 
 // AnnotateSwiftObject add info from indexer to the swift.Object data object
@@ -64,7 +127,7 @@ func (f *Datafile) AnnotateSwiftObject(obj *swift.Object) {
 		logp.Critical("AnnotateSwiftObject: BUG: hash reference is nil")
 	}
 	f.Hash.AnnotateSwiftObject(obj)
-	obj.Annotate(*f)
+	obj.Annotate(f)
 }
 
 // ToSwiftObject creates annotated swift.Object data object for event publishing
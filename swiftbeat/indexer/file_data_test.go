@@ -0,0 +1,74 @@
+// +build !integration
+
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadMetadata(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	path := filepath.Join(node.devPath, "1488413430.12345.data")
+	writeFile(t, path, 0)
+
+	_, err := readMetadata(nil, path)
+	assert.Equal(t, ErrMetadataMissing, err)
+
+	// metadata split in chunks is read as a whole
+	metadata := map[string]string{"name": "/a/c/o", "X-Object-Meta-Note": strings.Repeat("x", 1000)}
+	writeMetadata(t, path, metadata, 256)
+	data, err := readMetadata(nil, path)
+	if assert.NoError(t, err) {
+		assert.True(t, len(data) > 1000)
+	}
+}
+
+func TestObjectMetadata(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	// user metadata of the object is split in chunks, the chunks are kept
+	// small as some filesystems limit the xattrs of a file to one block
+	metadata := map[string]string{
+		"name":                       "/a/c/o",
+		"X-Timestamp":                "1488413430.12345",
+		"Content-Length":             "5",
+		"Content-Type":               "text/plain",
+		"X-Object-Sysmeta-Container": "c",
+		"X-Unknown":                  "dropped",
+	}
+	for i := 0; i < 20; i++ {
+		metadata[fmt.Sprintf("X-Object-Meta-Key%d", i)] = strings.Repeat("v", 64)
+	}
+
+	path := filepath.Join(node.hashDir(t, "objects", "/a/c/o"), "1488413430.12345.data")
+	writeFile(t, path, 5)
+	writeMetadata(t, path, metadata, 256)
+
+	events := node.scan(t, map[string]interface{}{
+		"partition_index_only":  false,
+		"enable_datafile_index": true,
+	}, nil)
+
+	objects := eventsOf(events, "object")
+	if !assert.Len(t, objects, 1) {
+		return
+	}
+	object := objects[0]
+	assert.Equal(t, "/a/c/o", object["name"])
+	assert.Equal(t, int64(5), object["content-length"])
+	assert.Equal(t, "text/plain", object["content-type"])
+	assert.Equal(t, "c", object["x-object-sysmeta-container"])
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, strings.Repeat("v", 64), object[fmt.Sprintf("x-object-meta-key%d", i)])
+	}
+	assert.NotContains(t, object, "x-unknown")
+}
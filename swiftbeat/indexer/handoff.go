@@ -0,0 +1,144 @@
+package indexer
+
+import (
+	"time"
+
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// handoffPart keeps the stats of one handoff partition found during a scan
+type handoffPart struct {
+	numDatafiles int64
+	bytesTotal   int64
+}
+
+// handoffState keeps handoff partitions seen by the last scan of a resource
+// to track how fast handoffs are drained between scans
+type handoffState struct {
+	parts     map[int64]handoffPart
+	scannedAt time.Time
+}
+
+// recordHandoff adds a handoff partition to the stats of the current scan
+func (r *Resource) recordHandoff(p *Partition) {
+	part := handoffPart{
+		numDatafiles: p.NumDatafiles,
+		bytesTotal:   p.BytesTotal,
+	}
+
+	// unchanged partition skipped by incremental scan keeps previous stats
+	if p.unchanged {
+		part = r.prevHandoffPart(p.PartId)
+	}
+
+	r.handoffLock.Lock()
+	defer r.handoffLock.Unlock()
+
+	r.handoffParts[p.PartId] = part
+}
+
+// prevHandoffPart returns stats of the handoff partition from previous scan
+// stats are unknown (-1) if the partition was not found
+func (r *Resource) prevHandoffPart(partId int64) handoffPart {
+	r.Disk.handoffsLock.Lock()
+	defer r.Disk.handoffsLock.Unlock()
+
+	if prev := r.Disk.handoffs[ringKey(r.Type, r.PolicyIndex)]; prev != nil {
+		if part, ok := prev.parts[partId]; ok {
+			return part
+		}
+	}
+	return handoffPart{numDatafiles: -1, bytesTotal: -1}
+}
+
+// helper function to sum up handoff partition stats
+// -1 is returned if stats are not available in partition index only mode
+func sumHandoffParts(parts map[int64]handoffPart) (numDatafiles int64, bytesTotal int64) {
+	for _, part := range parts {
+		if part.bytesTotal < 0 {
+			return -1, -1
+		}
+		numDatafiles += part.numDatafiles
+		bytesTotal += part.bytesTotal
+	}
+	return
+}
+
+// handoffSummary compares handoff partitions found in this scan against the
+// previous scan and returns the handoff summary event
+func (r *Resource) handoffSummary() input.Event {
+	r.handoffLock.Lock()
+	cur := &handoffState{
+		parts:     r.handoffParts,
+		scannedAt: time.Now(),
+	}
+	r.handoffLock.Unlock()
+
+	key := ringKey(r.Type, r.PolicyIndex)
+
+	r.Disk.handoffsLock.Lock()
+	prev := r.Disk.handoffs[key]
+	r.Disk.handoffs[key] = cur
+	r.Disk.handoffsLock.Unlock()
+
+	numDatafiles, bytesTotal := sumHandoffParts(cur.parts)
+
+	summary := swift.HandoffSummary{
+		ResourceType:      r.Type,
+		PolicyIndex:       r.PolicyIndex,
+		PolicyName:        r.PolicyName,
+		Device:            r.DevName,
+		Ip:                r.Ip,
+		ScannedAt:         cur.scannedAt,
+		RingMtime:         r.RingMtime,
+		NumParts:          int64(len(r.partitions)),
+		NumHandoffParts:   int64(len(cur.parts)),
+		NumDatafiles:      numDatafiles,
+		BytesTotalMB:      -1,
+		NumDrainedParts:   -1,
+		NumNewParts:       -1,
+		DrainPartsPerHour: -1,
+		DrainMBPerHour:    -1,
+		DrainETAHours:     -1,
+	}
+	if bytesTotal >= 0 {
+		summary.BytesTotalMB = bytesTotal / 1024 / 1024
+	}
+
+	// rate estimates are only available from the second scan on
+	if prev == nil {
+		return input.NewHandoffSummaryEvent(summary)
+	}
+
+	summary.PrevScannedAt = prev.scannedAt
+	summary.NumDrainedParts = 0
+	summary.NumNewParts = 0
+	for partId := range prev.parts {
+		if _, ok := cur.parts[partId]; !ok {
+			summary.NumDrainedParts += 1
+		}
+	}
+	for partId := range cur.parts {
+		if _, ok := prev.parts[partId]; !ok {
+			summary.NumNewParts += 1
+		}
+	}
+
+	elapsed := cur.scannedAt.Sub(prev.scannedAt).Hours()
+	if elapsed <= 0 {
+		return input.NewHandoffSummaryEvent(summary)
+	}
+
+	summary.DrainPartsPerHour = float64(summary.NumDrainedParts) / elapsed
+	if summary.DrainPartsPerHour > 0 {
+		summary.DrainETAHours = float64(summary.NumHandoffParts) / summary.DrainPartsPerHour
+	}
+
+	_, prevBytesTotal := sumHandoffParts(prev.parts)
+	if bytesTotal >= 0 && prevBytesTotal >= 0 {
+		summary.DrainMBPerHour = float64(prevBytesTotal-bytesTotal) / 1024 / 1024 / elapsed
+	}
+
+	return input.NewHandoffSummaryEvent(summary)
+}
@@ -0,0 +1,135 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+func TestHandoffSummaryScan(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	// partition 1 is a handoff on sdb, partition 0 is primary
+	for _, part := range []uint64{0, 1} {
+		dir := node.hashDir(t, "objects", node.objectName(t, part))
+		writeFile(t, filepath.Join(dir, "1488413430.12345.data"), 1024*1024)
+	}
+
+	tests := []struct {
+		settings     map[string]interface{}
+		numDatafiles int64
+		bytesTotalMB int64
+	}{
+		// stats are not available in partition index only mode
+		{nil, -1, -1},
+		{map[string]interface{}{"partition_index_only": false}, 1, 1},
+	}
+
+	for _, test := range tests {
+		events := node.scan(t, test.settings, nil)
+
+		summaries := eventsOf(events, "handoff_summary")
+		if !assert.Len(t, summaries, 1) {
+			continue
+		}
+		summary := summaries[0]
+		assert.Equal(t, "object", summary["resource_type"])
+		assert.Equal(t, "127.0.0.1", summary["ip"])
+		assert.Equal(t, int64(2), summary["num_parts"])
+		assert.Equal(t, int64(1), summary["num_handoff_parts"])
+		assert.Equal(t, test.numDatafiles, summary["num_datafiles"])
+		assert.Equal(t, test.bytesTotalMB, summary["bytes_total_mb"])
+		// rates need a previous scan
+		assert.Equal(t, int64(-1), summary["num_drained_parts"])
+		assert.Equal(t, float64(-1), summary["drain_parts_per_hour"])
+		assert.NotContains(t, summary, "prev_scanned_at")
+	}
+}
+
+func TestHandoffSummaryDrain(t *testing.T) {
+	const mb = 1024 * 1024
+
+	tests := []struct {
+		name         string
+		parts        map[int64]handoffPart
+		drained      int64
+		added        int64
+		partsPerHour float64
+		mbPerHour    float64
+		etaHours     float64
+	}{
+		{
+			name: "nothing drained",
+			parts: map[int64]handoffPart{
+				1: {numDatafiles: 2, bytesTotal: 4 * mb},
+				5: {numDatafiles: 1, bytesTotal: 2 * mb},
+			},
+			mbPerHour: 0,
+			etaHours:  -1,
+		},
+		{
+			name: "partially replicated away",
+			parts: map[int64]handoffPart{
+				5: {numDatafiles: 1, bytesTotal: 2 * mb},
+			},
+			drained:      1,
+			partsPerHour: 0.5,
+			mbPerHour:    2,
+			etaHours:     2,
+		},
+		{
+			name: "new handoff after rebalance",
+			parts: map[int64]handoffPart{
+				5: {numDatafiles: 1, bytesTotal: 2 * mb},
+				7: {numDatafiles: 1, bytesTotal: 2 * mb},
+			},
+			drained:      1,
+			added:        1,
+			partsPerHour: 0.5,
+			mbPerHour:    1,
+			etaHours:     4,
+		},
+		{
+			name: "stats unknown in partition index only mode",
+			parts: map[int64]handoffPart{
+				5: {numDatafiles: -1, bytesTotal: -1},
+			},
+			drained:      1,
+			partsPerHour: 0.5,
+			mbPerHour:    -1,
+			etaHours:     2,
+		},
+	}
+
+	for _, test := range tests {
+		disk := &Disk{handoffs: map[string]*handoffState{
+			ringKey("object", 0): {
+				parts: map[int64]handoffPart{
+					1: {numDatafiles: 2, bytesTotal: 4 * mb},
+					5: {numDatafiles: 1, bytesTotal: 2 * mb},
+				},
+				scannedAt: time.Now().Add(-2 * time.Hour),
+			},
+		}}
+		res := &Resource{Disk: disk, Type: "object", handoffParts: test.parts}
+
+		summary := res.handoffSummary().(*input.HandoffSummaryEvent).Summary
+		assert.Equal(t, int64(len(test.parts)), summary.NumHandoffParts, test.name)
+		assert.Equal(t, test.drained, summary.NumDrainedParts, test.name)
+		assert.Equal(t, test.added, summary.NumNewParts, test.name)
+		assert.InDelta(t, test.partsPerHour, summary.DrainPartsPerHour, 0.01, test.name)
+		assert.InDelta(t, test.mbPerHour, summary.DrainMBPerHour, 0.01, test.name)
+		assert.InDelta(t, test.etaHours, summary.DrainETAHours, 0.01, test.name)
+
+		// the current scan is kept for the next one
+		assert.Equal(t, test.parts, disk.handoffs[ringKey("object", 0)].parts, test.name)
+	}
+}
@@ -1,7 +1,6 @@
 package indexer
 
 import (
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -16,6 +15,8 @@ type Hash struct {
 	*IndexRecord
 	*Suffix
 	files []*FileRecord
+	// datafiles with metadata read, shared by the index and the checks
+	datafiles map[string]*Datafile
 }
 
 type HashSorter []*Hash
@@ -54,9 +55,10 @@ func (h *Hash) init() error {
 
 	var ifiles FileRecordSorter
 
-	files, err := ioutil.ReadDir(path)
+	files, err := readDir(h.limiter, path)
 	if err != nil {
 		logp.Err("list dir(%s) failed: %v", path, err)
+		h.incrError(errListDir)
 		return err
 	}
 
@@ -71,14 +73,46 @@ func (h *Hash) init() error {
 
 	sort.Sort(ifiles)
 	h.files = ifiles
+	h.datafiles = map[string]*Datafile{}
 	return nil
 }
 
+// datafile returns the datafile of a file under the hash dir with its
+// metadata read, xattrs are read once however many checks look at them
+func (h *Hash) datafile(file *FileRecord) *Datafile {
+	if dfile, ok := h.datafiles[file.Name]; ok {
+		return dfile
+	}
+
+	dfile, _ := NewDatafile(file)
+	dfile.Index()
+	h.datafiles[file.Name] = dfile
+	return dfile
+}
+
 func (h *Hash) buildObjectPartitionIndex() {
 	if len(h.files) <= 0 {
 		return
 	}
 
+	// every fragment archive of the current version held for the object is
+	// counted, unless the object is deleted
+	if h.isEC() && !strings.HasSuffix(h.files[0].Name, ".ts") {
+		frags := newestFragments(h.fragments())
+		expected := h.Partition.expectedFragIndex()
+		for _, frag := range frags {
+			h.Partition.NumDatafiles += 1
+			h.Partition.BytesTotal += frag.file.Size
+			if !frag.durable {
+				h.Partition.NumNonDurableFrags += 1
+			}
+			if expected >= 0 && frag.index != expected {
+				h.Partition.NumMisplacedFrags += 1
+			}
+		}
+		return
+	}
+
 	// sort in descedent mtime order
 	file := h.files[0]
 	// everything inside a partition is serialized
@@ -91,30 +125,79 @@ func (h *Hash) buildObjectPartitionIndex() {
 }
 
 func (h *Hash) buildDatafileIndex() {
+	// durable markers are newer than fragment archives, so all files are
+	// looked at instead of the newest ones
+	if h.isEC() {
+		expected := h.Partition.expectedFragIndex()
+		for _, frag := range h.fragments() {
+			dfile := h.datafile(frag.file)
+			dfile.FragIndex = frag.index
+			dfile.Durable = frag.durable
+			dfile.FragMisplaced = expected >= 0 && frag.index != expected
+			if !h.indexDatafile(dfile) {
+				return
+			}
+		}
+		return
+	}
+
 	for _, file := range h.files {
 		if !strings.HasSuffix(file.Name, ".data") {
 			return
 		}
 
-		dfile, _ := NewDatafile(file)
-		dfile.Index()
+		if !h.indexDatafile(h.datafile(file)) {
+			return
+		}
+	}
+}
+
+// indexDatafile publishes the object event of the datafile
+// It returns false if the event is dropped due to cancellation
+func (h *Hash) indexDatafile(dfile *Datafile) bool {
+	h.incr(statFiles)
+
+	event := input.NewObjectEvent(dfile.ToSwiftObject())
+	if !h.publish(event) {
+		return false
+	}
 
-		event := input.NewObjectEvent(dfile.ToSwiftObject())
-		h.eventChan <- event
+	logp.Debug("datafile", "Event generated for %s - Dump %s",
+		dfile.Path, dfile.Metadata)
+	return true
+}
 
-		logp.Debug("datafile", "Event generated for %s - Dump %s",
-			dfile.Path, dfile.Metadata)
+// helper function to find a file under the hash dir by name
+func (h *Hash) findFile(name string) *FileRecord {
+	for _, file := range h.files {
+		if file.Name == name {
+			return file
+		}
 	}
+	return nil
 }
 
 func (h *Hash) buildDBIndex() {
 	for _, file := range h.files {
+		// pending file can be newer than db file so skip instead of stop
 		if !strings.HasSuffix(file.Name, ".db") {
-			return
+			continue
 		}
 
 		if h.Type == "container" {
 			dbfile, _ := NewContainerDBfile(file)
+			// deep stats are skipped for huge dbs to limit IO
+			if h.config.EnableContainerDBStats {
+				if file.Size <= h.config.ContainerDBStatsMaxSize {
+					dbfile.deepStats = true
+				} else {
+					logp.Debug("hash", "Skip deep stats for db(%s) of size %d",
+						file.Path, file.Size)
+				}
+			}
+			if pending := h.findFile(file.Name + ".pending"); pending != nil {
+				dbfile.pending_size = pending.Size
+			}
 			h.IndexableQ = append(h.IndexableQ, dbfile)
 		} else if h.Type == "account" {
 			dbfile, _ := NewAccountDBfile(file)
@@ -129,20 +212,45 @@ func (h *Hash) buildDBIndex() {
 func (h *Hash) BuildIndex() {
 	logp.Debug("hash", "Start building index for hash: %s", h.Path)
 
+	// hash dir mtime advances whenever an object file is added or removed
+	changed := h.since.IsZero() || h.Mtime.After(h.since)
+
+	// unchanged hash dir is still listed for partition level stats
+	if h.Type == "object" && !changed && !h.config.EnableObjectPartitionIndex {
+		return
+	}
+
 	// load file list for the hash
 	err := h.init()
 	if err != nil {
 		return
 	}
+	h.incr(statHashes)
 
 	if h.Type == "object" {
 		if h.config.EnableObjectPartitionIndex {
 			h.buildObjectPartitionIndex()
 		}
 
+		if h.config.EnableExpiredCheck {
+			h.checkExpired()
+		}
+
+		if !changed {
+			return
+		}
+
 		if h.config.EnableDatafileIndex {
 			h.buildDatafileIndex()
 		}
+
+		if h.config.EnableAudit {
+			h.audit()
+		}
+
+		if h.config.EnablePlacementCheck {
+			h.checkPlacement()
+		}
 	} else if h.Type == "container" || h.Type == "account" {
 		h.buildDBIndex()
 	}
@@ -154,5 +262,5 @@ func (h *Hash) AnnotateSwiftObject(obj *swift.Object) {
 		logp.Critical("AnnotateSwiftObject: BUG: suffix reference is nil")
 	}
 	h.Suffix.AnnotateSwiftObject(obj)
-	obj.Annotate(*h)
+	obj.Annotate(h)
 }
@@ -0,0 +1,112 @@
+package indexer
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	pickle "github.com/hydrogen18/stalecucumber"
+
+	"github.com/elastic/beats/libbeat/logp"
+)
+
+const (
+	hashesFile = "hashes.pkl"
+)
+
+// helper function to tell suffix dir name from other partition entries
+func isSuffixName(name string) bool {
+	if len(name) != 3 {
+		return false
+	}
+	for _, c := range name {
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// loadHashes unpickles the suffix hashes dict, suffixes to be rehashed are
+// mapped to empty hash
+func loadHashes(r io.Reader) (map[string]string, error) {
+	dict, err := pickle.Dict(pickle.Unpickle(r))
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := map[string]string{}
+	for key, value := range dict {
+		suffix, ok := key.(string)
+		if !ok || !isSuffixName(suffix) {
+			continue
+		}
+		hash, _ := value.(string)
+		hashes[suffix] = hash
+	}
+	return hashes, nil
+}
+
+// indexHashes reads hashes.pkl of the partition and compares it against the
+// suffix dirs on disk to tell which suffixes still need to be rehashed
+func (p *Partition) indexHashes() error {
+	path := filepath.Join(p.Path, hashesFile)
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			logp.Debug("partition", "No %s found for partition: %s", hashesFile, p.Path)
+			return nil
+		}
+		logp.Err("stat file(%s) failed: %v", path, err)
+		p.incrError(errHashes)
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		logp.Err("open file(%s) failed: %v", path, err)
+		p.incrError(errHashes)
+		return err
+	}
+	defer f.Close()
+
+	hashes, err := loadHashes(f)
+	if err != nil {
+		logp.Err("unpickling file(%s) failed: %v", path, err)
+		p.incrError(errHashes)
+		return err
+	}
+
+	files, err := readDir(p.limiter, p.Path)
+	if err != nil {
+		logp.Err("list dir(%s) failed: %v", p.Path, err)
+		p.incrError(errListDir)
+		return err
+	}
+
+	p.HashesMtime = fi.ModTime()
+	p.NumSuffixes = int64(len(hashes))
+	p.NumInvalidSuffixes = 0
+	p.NumMissingSuffixes = 0
+
+	p.suffixHashes = map[string]string{}
+	for suffix, hash := range hashes {
+		if hash == "" {
+			p.NumInvalidSuffixes += 1
+			continue
+		}
+		p.suffixHashes[suffix] = hash
+	}
+
+	for _, file := range files {
+		if !file.IsDir() || !isSuffixName(file.Name()) {
+			continue
+		}
+		if _, ok := hashes[file.Name()]; !ok {
+			p.NumMissingSuffixes += 1
+		}
+	}
+
+	return nil
+}
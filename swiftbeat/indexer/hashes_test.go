@@ -0,0 +1,137 @@
+// +build !integration
+
+package indexer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pickle "github.com/hydrogen18/stalecucumber"
+	"github.com/stretchr/testify/assert"
+)
+
+// writeHashes pickles the suffix hashes into hashes.pkl of the partition dir,
+// nil hashes are pickled as None
+func writeHashes(t *testing.T, dir string, hashes map[string]interface{}) {
+	var buf bytes.Buffer
+	if _, err := pickle.NewPickler(&buf).Pickle(hashes); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, hashesFile), buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestIsSuffixName(t *testing.T) {
+	tests := []struct {
+		name   string
+		suffix bool
+	}{
+		{"abc", true},
+		{"09f", true},
+		{"ABC", false},
+		{"abg", false},
+		{"ab", false},
+		{"abcd", false},
+		{hashesFile, false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.suffix, isSuffixName(test.name), test.name)
+	}
+}
+
+func TestLoadHashes(t *testing.T) {
+	tests := []struct {
+		name   string
+		hashes map[string]interface{}
+		loaded map[string]string
+	}{
+		{
+			name:   "empty",
+			hashes: map[string]interface{}{},
+			loaded: map[string]string{},
+		},
+		{
+			name: "valid and invalidated suffixes",
+			hashes: map[string]interface{}{
+				"abc": "d41d8cd98f00b204e9800998ecf8427e",
+				"def": nil,
+			},
+			loaded: map[string]string{
+				"abc": "d41d8cd98f00b204e9800998ecf8427e",
+				"def": "",
+			},
+		},
+		{
+			name: "entries other than suffixes are ignored",
+			hashes: map[string]interface{}{
+				"abc":     "d41d8cd98f00b204e9800998ecf8427e",
+				"updated": 1488413430.12345,
+			},
+			loaded: map[string]string{
+				"abc": "d41d8cd98f00b204e9800998ecf8427e",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		var buf bytes.Buffer
+		if _, err := pickle.NewPickler(&buf).Pickle(test.hashes); err != nil {
+			t.Fatal(err)
+		}
+
+		hashes, err := loadHashes(&buf)
+		if assert.NoError(t, err, test.name) {
+			assert.Equal(t, test.loaded, hashes, test.name)
+		}
+	}
+
+	_, err := loadHashes(strings.NewReader("garbage"))
+	assert.Error(t, err)
+}
+
+func TestIndexHashes(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	// abc is up to date, def is invalidated and fed is not hashed yet
+	part := node.mkdir(t, "objects", "0")
+	for _, suffix := range []string{"abc", "def", "fed"} {
+		node.mkdir(t, "objects", "0", suffix)
+	}
+	writeHashes(t, part, map[string]interface{}{
+		"abc": "d41d8cd98f00b204e9800998ecf8427e",
+		"def": nil,
+	})
+	// no hashes.pkl yet
+	node.mkdir(t, "objects", "2", "abc")
+
+	tests := []struct {
+		enabled bool
+		parts   map[int64][]int64
+	}{
+		{false, map[int64][]int64{0: {-1, -1, -1}, 2: {-1, -1, -1}}},
+		{true, map[int64][]int64{0: {2, 1, 1}, 2: {-1, -1, -1}}},
+	}
+
+	for _, test := range tests {
+		events := node.scan(t, map[string]interface{}{"enable_hashes_index": test.enabled}, nil)
+
+		parts := map[int64][]int64{}
+		for _, part := range eventsOf(events, "obj_partition") {
+			parts[part["partition"].(int64)] = []int64{
+				part["num_suffixes"].(int64),
+				part["num_invalid_suffixes"].(int64),
+				part["num_missing_suffixes"].(int64),
+			}
+			_, ok := part["hashes_mtime"]
+			assert.Equal(t, test.enabled && part["partition"] == int64(0), ok)
+		}
+		assert.Equal(t, test.parts, parts)
+	}
+}
@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+// scanSince returns the time the partition was last completely walked, dirs
+// not modified since then can be skipped. Zero time means a full scan
+//
+// Only object partitions are scanned incrementally since objects are written
+// as new files, which always advances the mtime of the hash dir. DBs are
+// updated in place without touching any dir
+func (p *Partition) scanSince() time.Time {
+	if !p.config.EnableIncrementalScan || p.states == nil {
+		return time.Time{}
+	}
+
+	// scan state is recorded once all events of the partition are published
+	scanState := p.states.FindScanState(p.ToSwiftPartition())
+	if scanState == nil {
+		return time.Time{}
+	}
+
+	// partition assignment might have changed with the ring
+	if p.RingMtime.Unix() > scanState.LastRingMtime.Unix() {
+		return time.Time{}
+	}
+
+	// force a refresh once the last full scan is too old
+	if p.rescanOlder > 0 && time.Since(scanState.LastIndexed) > p.rescanOlder {
+		return time.Time{}
+	}
+
+	return scanState.LastIndexed
+}
+
+// modifiedSince tells whether the partition dir, any of its entries or any
+// hash dir under its suffix dirs is modified since the given time. Object
+// overwrites only touch the hash dir, the suffix dirs are listed to find it
+func (p *Partition) modifiedSince(files []os.FileInfo, since time.Time) bool {
+	if since.IsZero() || p.Mtime.After(since) {
+		return true
+	}
+	for _, file := range files {
+		if file.ModTime().After(since) {
+			return true
+		}
+	}
+
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+		if p.stopped() {
+			return true
+		}
+
+		path := filepath.Join(p.Path, file.Name())
+		hashes, err := readDir(p.limiter, path)
+		if err != nil {
+			// let the walk report the error
+			logp.Debug("partition", "list dir(%s) failed: %v", path, err)
+			return true
+		}
+		for _, hash := range hashes {
+			if hash.ModTime().After(since) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// recordScan publishes the scan state of the object partition, after all
+// other events of the partition so it advances only once they are persisted
+// It is recorded in every index mode, and for skipped partitions as well to
+// keep their states active
+func (p *Partition) recordScan() {
+	if !p.config.EnableIncrementalScan || p.states == nil {
+		return
+	}
+
+	scan := input.PartitionScan{
+		Partition: p.ToSwiftPartition(),
+		Unchanged: p.unchanged,
+	}
+	if !p.unchanged {
+		for _, suffix := range p.suffixes {
+			for _, hash := range suffix.hashes {
+				scan.Hashes = append(scan.Hashes, hash.Name)
+			}
+		}
+	}
+	p.publish(input.NewPartitionScanEvent(scan))
+}
@@ -0,0 +1,73 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+func TestIncrementalScan(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	// one object in partition 0 and 2 each, written before the first scan
+	hashDirs := map[int64]string{}
+	for _, partId := range []uint64{0, 2} {
+		name := node.objectName(t, partId)
+		dir := node.hashDir(t, "objects", name)
+		path := filepath.Join(dir, "1488413430.12345.data")
+		writeFile(t, path, 0)
+		writeMetadata(t, path, map[string]string{"name": name, "X-Timestamp": "1488413430.12345"}, 0)
+		hashDirs[int64(partId)] = dir
+	}
+	past := time.Now().Add(-time.Hour)
+	filepath.Walk(filepath.Join(node.devPath, "objects"), func(path string, info os.FileInfo, err error) error {
+		return os.Chtimes(path, past, past)
+	})
+
+	settings := map[string]interface{}{
+		"partition_index_only":    false,
+		"enable_datafile_index":   true,
+		"enable_incremental_scan": true,
+	}
+	states := input.NewStates()
+
+	// scan returns the partitions of the object events and the partitions
+	// found unchanged, states are updated like the prospector does
+	scan := func() (map[int64]bool, map[int64]bool) {
+		indexed, unchanged := map[int64]bool{}, map[int64]bool{}
+		for _, event := range node.scan(t, settings, states) {
+			if scan, ok := event.(*input.PartitionScanEvent); ok && scan.Scan.Unchanged {
+				unchanged[scan.Scan.Partition.PartId] = true
+			}
+			if m := event.ToMapStr(); m["type"] == "object" {
+				indexed[m["partition"].(int64)] = true
+			}
+			states.Update(event)
+		}
+		return indexed, unchanged
+	}
+
+	indexed, unchanged := scan()
+	assert.Equal(t, map[int64]bool{0: true, 2: true}, indexed, "first scan")
+	assert.Equal(t, map[int64]bool{}, unchanged, "first scan")
+
+	indexed, unchanged = scan()
+	assert.Equal(t, map[int64]bool{}, indexed, "nothing changed")
+	assert.Equal(t, map[int64]bool{0: true, 2: true}, unchanged, "nothing changed")
+
+	// object overwritten in partition 2 only touches its hash dir
+	now := time.Now().Add(time.Minute)
+	os.Chtimes(hashDirs[2], now, now)
+
+	indexed, unchanged = scan()
+	assert.Equal(t, map[int64]bool{2: true}, indexed, "hash dir modified")
+	assert.Equal(t, map[int64]bool{0: true}, unchanged, "hash dir modified")
+}
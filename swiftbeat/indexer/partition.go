@@ -1,14 +1,14 @@
 package indexer
 
 import (
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
-	"strings"
 	"time"
 
+	"github.com/openstack/swift/go/hummingbird"
+
 	"github.com/elastic/beats/libbeat/logp"
 	"github.com/elastic/beats/swiftbeat/input"
 	"github.com/elastic/beats/swiftbeat/input/swift"
@@ -17,7 +17,6 @@ import (
 type Partition struct {
 	*IndexRecord
 	*Resource
-	// TODO: hashes.pkl
 	suffixes      []*Suffix
 	Handoff       bool
 	PeerDevices   []string
@@ -29,6 +28,23 @@ type Partition struct {
 	PartId        int64
 	ReplicaId     int64
 	IndexableQ    []IndexableFile
+	// suffix hash state from hashes.pkl
+	HashesMtime        time.Time
+	NumSuffixes        int64
+	NumInvalidSuffixes int64
+	NumMissingSuffixes int64
+	// fragment archive state of EC policies
+	NumNonDurableFrags int64
+	NumMisplacedFrags  int64
+	// objects past X-Delete-At still on disk
+	NumExpired   int64
+	ExpiredBytes int64
+	// dirs not modified since then are skipped in incremental scan
+	since     time.Time
+	unchanged bool
+	// valid suffix hashes and peer devices compared by replica audit
+	suffixHashes map[string]string
+	peers        []*hummingbird.Device
 }
 
 type PartitionSorter []*Partition
@@ -63,6 +79,16 @@ func NewPartition(
 		PartId:        -1,
 		ReplicaId:     -1,
 		IndexableQ:    []IndexableFile{},
+		// -1 means hashes.pkl is not indexed or not found
+		NumSuffixes:        -1,
+		NumInvalidSuffixes: -1,
+		NumMissingSuffixes: -1,
+		// -1 means not an EC policy or fragments are not indexed
+		NumNonDurableFrags: -1,
+		NumMisplacedFrags:  -1,
+		// -1 means expiry is not checked
+		NumExpired:   -1,
+		ExpiredBytes: -1,
 	}
 
 	if i, err := strconv.ParseInt(part.Name, 10, 64); err == nil {
@@ -83,6 +109,7 @@ func (p *Partition) init() error {
 	p.Handoff = handoff
 
 	// add peer device and Ip info
+	p.peers = nodes
 	for _, n := range nodes {
 		p.PeerDevices = append(p.PeerDevices, n.Device)
 		p.PeerIps = append(p.PeerIps, n.Ip)
@@ -99,6 +126,11 @@ func (p *Partition) init() error {
 		}
 	}
 
+	// hashes.pkl is maintained for object partitions only
+	if p.Type == "object" && p.config.EnableHashesIndex {
+		p.indexHashes()
+	}
+
 	// stops at partition level to avoid heavy loads
 	if p.config.PartitionIndexOnly {
 		p.NumDatafiles = -1
@@ -107,12 +139,34 @@ func (p *Partition) init() error {
 		return nil
 	}
 
-	files, err := ioutil.ReadDir(path)
+	files, err := readDir(p.limiter, path)
 	if err != nil {
 		logp.Err("list dir(%s) failed: %v", path, err)
+		p.incrError(errListDir)
 		return err
 	}
 
+	// nothing under the partition changed since the last complete scan
+	if p.Type == "object" {
+		p.since = p.scanSince()
+		if !p.modifiedSince(files, p.since) {
+			logp.Debug("partition", "Skip unchanged partition: %s", path)
+			p.unchanged = true
+			return nil
+		}
+	}
+
+	// fragment archives are counted along with the datafiles
+	if p.isEC() && p.config.EnableObjectPartitionIndex {
+		p.NumNonDurableFrags = 0
+		p.NumMisplacedFrags = 0
+	}
+
+	if p.config.EnableExpiredCheck {
+		p.NumExpired = 0
+		p.ExpiredBytes = 0
+	}
+
 	var suffixes SuffixSorter
 	for _, file := range files {
 		if !file.IsDir() {
@@ -135,6 +189,9 @@ func (p *Partition) buildSuffixIndex() {
 	}
 
 	for _, suffix := range p.suffixes {
+		if p.stopped() {
+			return
+		}
 		suffix.BuildIndex()
 	}
 }
@@ -143,13 +200,29 @@ func (p *Partition) buildSuffixIndex() {
 // It is a blocking call and return after finishing index build for all
 // suffixes under the partition
 func (p *Partition) BuildIndex() {
-	defer p.Resource.sem.release()
-
 	logp.Debug("partition", "Start building index for partition: %s", p.Path)
 
+	p.buildIndex()
+
+	// replicas on peers might diverge regardless of local changes. Peers are
+	// queried after the partition indexer slot is released since it waits on
+	// remote hosts rather than on the local disk
+	if p.Type == "object" && p.config.EnableReplicaAudit && !p.Handoff && !p.stopped() {
+		p.auditReplicas()
+	}
+}
+
+// buildIndex walks the partition while holding a partition indexer slot
+func (p *Partition) buildIndex() {
 	// limit num of partition indexers can run simultaneously
 	// to avoid heavy IO hit
-	p.Resource.sem.acquire()
+	p.Disk.acquire()
+	defer p.Disk.release()
+
+	// partition might wait long for its turn
+	if p.stopped() {
+		return
+	}
 
 	// load suffix list for the partition
 	err := p.init()
@@ -157,31 +230,64 @@ func (p *Partition) BuildIndex() {
 		return
 	}
 
-	p.buildSuffixIndex()
+	if p.unchanged {
+		p.incr(statPartitionsSkipped)
+	} else {
+		p.incr(statPartitions)
+	}
+
 	// IndexedAt is set at partition level, need to happen before ToEvent()
+	// it is taken before walking the dirs to serve as the baseline of the
+	// next incremental scan
 	p.IndexedAt = time.Now()
 
+	p.buildSuffixIndex()
+	// partially indexed partition must not be reported as a whole
+	if p.stopped() {
+		return
+	}
+
 	switch p.Resource.Type {
 	case "account":
 		fallthrough
 	case "container":
 		sort.Sort(IndexableFileSorter(p.IndexableQ))
-		for _, f := range p.IndexableQ {
+		for i, f := range p.IndexableQ {
+			if p.stopped() {
+				return
+			}
+
 			f.Index()
+			p.incr(statFiles)
 			event := f.ToEvent()
 
 			//part := event.ToPartition()
 			//logp.Debug("hack", "77--> : %s - %s", event.ToMapStr()["path"], part.Mtime)
 
 			if event != nil {
-				p.eventChan <- event
+				// only the last event marks the partition completely indexed
+				if part := event.ToPartition(); part != nil {
+					part.Incomplete = i < len(p.IndexableQ)-1
+				}
+				if !p.publish(event) {
+					return
+				}
 			}
 		}
 	case "object":
-		if p.config.EnableObjectPartitionIndex {
+		if p.Handoff {
+			p.Resource.recordHandoff(p)
+		}
+
+		// state of unchanged partition is up to date already
+		if !p.unchanged && p.config.EnableObjectPartitionIndex {
 			event := input.NewObjectPartitionEvent(p.ToSwiftObjectPartition())
-			p.eventChan <- event
+			if !p.publish(event) {
+				return
+			}
 		}
+
+		p.recordScan()
 	}
 }
 
@@ -192,13 +298,36 @@ func (p *Partition) AnnotateSwiftObject(obj *swift.Object) {
 	}
 	p.Resource.AnnotateSwiftObject(obj)
 
-	obj.Annotate(*p)
+	obj.Annotate(p)
 
-	obj.PeerDevices = strings.Join(p.PeerDevices, ",")
-	obj.PeerIps = strings.Join(p.PeerIps, ",")
+	obj.PeerDevices = p.PeerDevices
+	obj.PeerIps = p.PeerIps
+	obj.Part = p.ToSwiftPartition()
 }
 
-// ToSwiftPartition creates annotated swift.ObjectPartition data object for event publishing
+// ToSwiftPartition creates annotated swift.Partition data object for event publishing
+func (p *Partition) ToSwiftPartition() *swift.Partition {
+	part := &swift.Partition{
+		PartId:    p.PartId,
+		Mtime:     p.Mtime,
+		IndexedAt: p.IndexedAt,
+		// fields inherited from parents
+		ResourceType: p.Type,
+		Device:       p.DevName,
+		Ip:           p.Ip,
+		RingMtime:    p.RingMtime,
+		Handoff:      p.Handoff,
+		ReplicaId:    p.ReplicaId,
+		PeerDevices:  p.PeerDevices,
+		PeerIps:      p.PeerIps,
+		RingCKSum:    p.RingCKSum,
+		PolicyIndex:  p.PolicyIndex,
+		PolicyName:   p.PolicyName,
+	}
+	return part
+}
+
+// ToSwiftObjectPartition creates annotated swift.ObjectPartition data object for event publishing
 func (p *Partition) ToSwiftObjectPartition() swift.ObjectPartition {
 	var bytesTotalMB int64
 	if p.BytesTotal == -1 {
@@ -208,24 +337,18 @@ func (p *Partition) ToSwiftObjectPartition() swift.ObjectPartition {
 	}
 
 	objPart := swift.ObjectPartition{
-		Partition: &swift.Partition{
-			PartId:    p.PartId,
-			Mtime:     p.Mtime,
-			IndexedAt: p.IndexedAt,
-			// fields inherited from parents
-			ResourceType: p.Type,
-			Device:       p.DevName,
-			Ip:           p.Ip,
-			RingMtime:    p.RingMtime,
-			Handoff:      p.Handoff,
-			ReplicaId:    p.ReplicaId,
-			PeerDevices:  strings.Join(p.PeerDevices, ","),
-			PeerIps:      strings.Join(p.PeerIps, ","),
-			RingCKSum:    p.RingCKSum,
-		},
-		NumDatafiles:  p.NumDatafiles,
-		NumTombstones: p.NumTombstones,
-		BytesTotalMB:  bytesTotalMB,
+		Partition:          p.ToSwiftPartition(),
+		NumDatafiles:       p.NumDatafiles,
+		NumTombstones:      p.NumTombstones,
+		BytesTotalMB:       bytesTotalMB,
+		HashesMtime:        p.HashesMtime,
+		NumSuffixes:        p.NumSuffixes,
+		NumInvalidSuffixes: p.NumInvalidSuffixes,
+		NumMissingSuffixes: p.NumMissingSuffixes,
+		NumNonDurableFrags: p.NumNonDurableFrags,
+		NumMisplacedFrags:  p.NumMisplacedFrags,
+		NumExpired:         p.NumExpired,
+		ExpiredBytes:       p.ExpiredBytes,
 	}
 	return objPart
 }
@@ -0,0 +1,87 @@
+package indexer
+
+import (
+	"crypto/md5"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// hashPath returns the hash dir name of an object path the same way Swift
+// computes it with the cluster hash prefix and suffix
+func hashPath(prefix string, suffix string, name string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(prefix+name+suffix)))
+}
+
+// helper function to split object name into account, container and object
+func splitObjectName(name string) (string, string, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(name, "/"), "/", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return "", "", "", fmt.Errorf("invalid object name: %s", name)
+	}
+	return parts[0], parts[1], parts[2], nil
+}
+
+// checkPlacement verifies the object stored under the hash dir belongs to the
+// hash dir and partition it is found in according to the ring
+func (h *Hash) checkPlacement() {
+	var data *FileRecord
+	for _, file := range h.files {
+		if strings.HasSuffix(file.Name, ".data") {
+			data = file
+			break
+		}
+	}
+	if data == nil {
+		return
+	}
+
+	dfile := h.datafile(data)
+	if dfile.MetadataErr != nil {
+		return
+	}
+
+	name, ok := dfile.Metadata["name"]
+	if !ok {
+		return
+	}
+
+	account, container, object, err := splitObjectName(name)
+	if err != nil {
+		logp.Err("placement check on file(%s) failed: %v", data.Path, err)
+		return
+	}
+
+	expectedHash := hashPath(h.Disk.swift.hashPathPrefix, h.Disk.swift.hashPathSuffix, name)
+	expectedPartId := int64(h.ring.GetPartition(account, container, object))
+
+	if expectedHash == h.Name && expectedPartId == h.Partition.PartId {
+		return
+	}
+
+	var primaryDevices, primaryIps []string
+	for _, n := range h.ring.GetNodesInOrder(uint64(expectedPartId)) {
+		primaryDevices = append(primaryDevices, n.Device)
+		primaryIps = append(primaryIps, n.Ip)
+	}
+
+	misplaced := swift.MisplacedObject{
+		Partition:      h.Partition.ToSwiftPartition(),
+		Name:           name,
+		Hash:           h.Name,
+		Path:           data.Path,
+		ExpectedPartId: expectedPartId,
+		ExpectedHash:   expectedHash,
+		PrimaryDevices: primaryDevices,
+		PrimaryIps:     primaryIps,
+		CheckedAt:      time.Now(),
+	}
+	h.publish(input.NewMisplacedObjectEvent(misplaced))
+
+	logp.Debug("hash", "Misplaced object %s found in %s, expected partition %d hash %s",
+		name, h.Path, expectedPartId, expectedHash)
+}
@@ -0,0 +1,98 @@
+// +build !integration
+
+package indexer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHashPath(t *testing.T) {
+	assert.Equal(t, "3c455f4c36c2927865b8822a4cef8a1f", hashPath("pre", "suf", "/a/c/o"))
+}
+
+func TestSplitObjectName(t *testing.T) {
+	tests := []struct {
+		name    string
+		account string
+		cont    string
+		object  string
+		err     bool
+	}{
+		{name: "/a/c/o", account: "a", cont: "c", object: "o"},
+		{name: "/a/c/o/with/slash", account: "a", cont: "c", object: "o/with/slash"},
+		{name: "/a/c", err: true},
+		{name: "/a//o", err: true},
+		{name: "", err: true},
+	}
+
+	for _, test := range tests {
+		account, cont, object, err := splitObjectName(test.name)
+		if test.err {
+			assert.Error(t, err, test.name)
+			continue
+		}
+		if assert.NoError(t, err, test.name) {
+			assert.Equal(t, []string{test.account, test.cont, test.object},
+				[]string{account, cont, object}, test.name)
+		}
+	}
+}
+
+func TestCheckPlacement(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	// writes the object under the given partition and hash dir
+	put := func(name string, partId uint64, hash string) {
+		dir := node.mkdir(t, "objects", fmt.Sprint(partId), hash[len(hash)-3:], hash)
+		path := filepath.Join(dir, "1488413430.12345.data")
+		writeFile(t, path, 0)
+		writeMetadata(t, path, map[string]string{"name": name, "X-Timestamp": "1488413430.12345"}, 0)
+	}
+
+	placed := node.objectName(t, 0)
+	put(placed, 0, hashPath("pre", "suf", placed))
+
+	// hash dir of another object in the right partition
+	wrongHash := node.objectName(t, 2)
+	put(wrongHash, 2, hashPath("pre", "suf", wrongHash+"x"))
+
+	// right hash dir left in another partition
+	wrongPart := node.objectName(t, 3)
+	put(wrongPart, 1, hashPath("pre", "suf", wrongPart))
+
+	tests := []struct {
+		enabled   bool
+		misplaced map[string]int64
+	}{
+		{false, map[string]int64{}},
+		{true, map[string]int64{wrongHash: 2, wrongPart: 3}},
+	}
+
+	for _, test := range tests {
+		events := node.scan(t, map[string]interface{}{
+			"partition_index_only":   false,
+			"enable_placement_check": test.enabled,
+		}, nil)
+
+		misplaced := map[string]int64{}
+		for _, m := range eventsOf(events, "misplaced_object") {
+			name := m["name"].(string)
+			misplaced[name] = m["expected_partition"].(int64)
+			assert.Equal(t, hashPath("pre", "suf", name), m["expected_hash"])
+
+			// replica r of partition p is on device (p + r) % 4 of the ring
+			var devices []string
+			for r := int64(0); r < 3; r++ {
+				devices = append(devices, testDevs[(m["expected_partition"].(int64)+r)%4].Device)
+			}
+			assert.Equal(t, devices, m["primary_devices"])
+		}
+		assert.Equal(t, test.misplaced, misplaced)
+	}
+}
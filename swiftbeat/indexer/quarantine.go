@@ -0,0 +1,67 @@
+package indexer
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// indexQuarantined reports items quarantined by auditors and replicators,
+// one event per quarantine type dir, i.e. objects, objects-N, containers
+// and accounts. Item age is taken from the mtime of the quarantined dir
+func (d *Disk) indexQuarantined() {
+	logp.Debug("indexer", "Start indexing quarantined dir: %s", d.quarantined)
+
+	dirs, err := readDir(d.limiter, d.quarantined)
+	if err != nil {
+		logp.Err("list dir(%s) failed: %v", d.quarantined, err)
+		return
+	}
+
+	for _, dir := range dirs {
+		if d.stopped() {
+			return
+		}
+		if !dir.IsDir() {
+			continue
+		}
+
+		resType, policy, err := parseResourceName(dir.Name())
+		if err != nil {
+			logp.Warn("skip quarantined dir(%s): %v", dir.Name(), err)
+			continue
+		}
+
+		path := filepath.Join(d.quarantined, dir.Name())
+		items, err := readDir(d.limiter, path)
+		if err != nil {
+			logp.Err("list dir(%s) failed: %v", path, err)
+			continue
+		}
+
+		quarantine := swift.Quarantine{
+			Device:         d.Name,
+			QuarantineType: dir.Name(),
+			ResourceType:   resType,
+			PolicyIndex:    policy,
+			NumItems:       int64(len(items)),
+		}
+		for _, item := range items {
+			mtime := item.ModTime()
+			if quarantine.OldestMtime.IsZero() || mtime.Before(quarantine.OldestMtime) {
+				quarantine.OldestMtime = mtime
+			}
+			if mtime.After(quarantine.NewestMtime) {
+				quarantine.NewestMtime = mtime
+			}
+		}
+		quarantine.ScannedAt = time.Now()
+
+		if !d.publish(input.NewQuarantineEvent(quarantine)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,61 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+func TestQuarantine(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	oldest := time.Now().Add(-48 * time.Hour)
+	for i, item := range []string{"abc", "def", "fed"} {
+		path := node.mkdir(t, "quarantined", "objects-1", item)
+		mtime := oldest.Add(time.Duration(i) * time.Hour)
+		os.Chtimes(path, mtime, mtime)
+	}
+	node.mkdir(t, "quarantined", "containers", "abc")
+	node.mkdir(t, "quarantined", "accounts")
+
+	events := node.scan(t, map[string]interface{}{"enable_quarantine_index": true}, nil)
+
+	quarantines := map[string]int64{}
+	for _, q := range eventsOf(events, "quarantined") {
+		quarantines[q["quarantine_type"].(string)] = q["num_items"].(int64)
+		if q["quarantine_type"] == "objects-1" {
+			assert.Equal(t, "object", q["resource_type"])
+			assert.Equal(t, int64(1), q["policy_index"])
+			assert.InDelta(t, 48, q["oldest_age_hours"], 0.1)
+			assert.InDelta(t, 46, q["newest_age_hours"], 0.1)
+		}
+	}
+	assert.Equal(t, map[string]int64{"objects-1": 3, "containers": 1, "accounts": 0}, quarantines)
+}
+
+func TestQuarantineStopped(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	for _, dir := range []string{"accounts", "containers", "objects", "objects-1"} {
+		node.mkdir(t, "quarantined", dir, "abc")
+	}
+
+	// nothing is walked nor published once the scan is cancelled
+	eventChan := make(chan input.Event, 10)
+	done := make(chan struct{})
+	disk := node.newDisk(t, map[string]interface{}{"enable_quarantine_index": true}, nil, eventChan, done)
+	disk.quarantined = filepath.Join(node.devPath, "quarantined")
+	close(done)
+
+	disk.indexQuarantined()
+	assert.Len(t, eventChan, 0)
+}
@@ -0,0 +1,271 @@
+package indexer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/openstack/swift/go/hummingbird"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// status of the partition replica on a peer device
+const (
+	replicaInSync      = "in_sync"
+	replicaOutOfSync   = "out_of_sync"
+	replicaMissing     = "missing"
+	replicaUnmounted   = "unmounted"
+	replicaUnreachable = "unreachable"
+	replicaError       = "error"
+)
+
+// replicaAuditor fetches suffix hashes of partition replicas on peer devices
+//
+// With the replicate source hashes are fetched from the object servers the
+// same way the replicator does. The peer answers a REPLICATE request with
+// get_hashes, which rehashes the suffixes invalidated since the last pass
+// and creates the partition dir if it is missing, so the audit puts IO load
+// on the peers and alters their disks. The local source reads hashes.pkl
+// from the devices dir instead, where all devices are mounted on a single
+// host as in a test cluster
+type replicaAuditor struct {
+	source  string
+	devices string
+	client  *http.Client
+	// peer hosts are looked up once per scan so recon is queried once and
+	// unreachable hosts are not retried on every partition
+	hosts     map[string]*peerHost
+	hostsLock sync.Mutex
+}
+
+type peerHost struct {
+	addr            string
+	reconOnce       sync.Once
+	replicationLast time.Time
+	down            bool
+}
+
+func newReplicaAuditor(config indexerConfig) *replicaAuditor {
+	return &replicaAuditor{
+		source:  config.ReplicaAuditSource,
+		devices: config.ReplicaAuditDevices,
+		client:  &http.Client{Timeout: config.ReplicaAuditTimeout},
+		hosts:   map[string]*peerHost{},
+	}
+}
+
+func (a *replicaAuditor) host(ip string, port int) *peerHost {
+	addr := net.JoinHostPort(ip, strconv.Itoa(port))
+
+	a.hostsLock.Lock()
+	defer a.hostsLock.Unlock()
+
+	h, ok := a.hosts[addr]
+	if !ok {
+		h = &peerHost{addr: addr}
+		a.hosts[addr] = h
+	}
+	return h
+}
+
+func (a *replicaAuditor) isDown(h *peerHost) bool {
+	a.hostsLock.Lock()
+	defer a.hostsLock.Unlock()
+	return h.down
+}
+
+func (a *replicaAuditor) setDown(h *peerHost) {
+	a.hostsLock.Lock()
+	defer a.hostsLock.Unlock()
+	h.down = true
+}
+
+// replicationLast returns the last object replication pass reported by recon
+// of the peer host, zero time if unknown
+func (a *replicaAuditor) replicationLast(dev *hummingbird.Device) time.Time {
+	h := a.host(dev.Ip, dev.Port)
+	h.reconOnce.Do(func() {
+		url := fmt.Sprintf("http://%s/recon/replication/object", h.addr)
+		resp, err := a.client.Get(url)
+		if err != nil {
+			logp.Debug("replica", "recon request(%s) failed: %v", url, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			logp.Debug("replica", "recon request(%s) failed: %s", url, resp.Status)
+			return
+		}
+
+		var recon struct {
+			ReplicationLast float64 `json:"object_replication_last"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&recon); err != nil {
+			logp.Debug("replica", "decode recon response(%s) failed: %v", url, err)
+			return
+		}
+		if recon.ReplicationLast > 0 {
+			h.replicationLast = time.Unix(0, int64(recon.ReplicationLast*float64(time.Second)))
+		}
+	})
+	return h.replicationLast
+}
+
+// replicateHashes fetches suffix hashes of the partition on the peer device
+// with a REPLICATE request, invalidated suffixes are rehashed on the peer
+func (a *replicaAuditor) replicateHashes(p *Partition, dev *hummingbird.Device) (map[string]string, string, string) {
+	ip, port := dev.ReplicationIp, dev.ReplicationPort
+	if ip == "" {
+		ip, port = dev.Ip, dev.Port
+	}
+
+	h := a.host(ip, port)
+	if a.isDown(h) {
+		return nil, replicaUnreachable, "host unreachable earlier in the scan"
+	}
+
+	url := fmt.Sprintf("http://%s/%s/%d", h.addr, dev.Device, p.PartId)
+	req, err := http.NewRequest("REPLICATE", url, nil)
+	if err != nil {
+		return nil, replicaError, err.Error()
+	}
+	req.Header.Set("X-Backend-Storage-Policy-Index", strconv.FormatInt(p.PolicyIndex, 10))
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		a.setDown(h)
+		return nil, replicaUnreachable, err.Error()
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusInsufficientStorage:
+		return nil, replicaUnmounted, resp.Status
+	default:
+		return nil, replicaError, resp.Status
+	}
+
+	hashes, err := loadHashes(resp.Body)
+	if err != nil {
+		return nil, replicaError, err.Error()
+	}
+	return hashes, "", ""
+}
+
+// localHashes reads hashes.pkl of the partition on the peer device mounted
+// under the devices dir
+func (a *replicaAuditor) localHashes(p *Partition, dev *hummingbird.Device) (map[string]string, string, string) {
+	devPath := filepath.Join(a.devices, dev.Device)
+	if _, err := os.Stat(devPath); err != nil {
+		return nil, replicaUnmounted, err.Error()
+	}
+
+	partPath := filepath.Join(devPath, p.Resource.Name, p.Name)
+	if _, err := os.Stat(partPath); os.IsNotExist(err) {
+		return map[string]string{}, "", ""
+	}
+
+	f, err := os.Open(filepath.Join(partPath, hashesFile))
+	if err != nil {
+		return nil, replicaError, err.Error()
+	}
+	defer f.Close()
+
+	hashes, err := loadHashes(f)
+	if err != nil {
+		return nil, replicaError, err.Error()
+	}
+	return hashes, "", ""
+}
+
+// auditPeer compares the local suffix hashes of the partition against the
+// replica on the peer device
+func (a *replicaAuditor) auditPeer(p *Partition, dev *hummingbird.Device) swift.PeerReplica {
+	peer := swift.PeerReplica{
+		Device: dev.Device,
+		Ip:     dev.Ip,
+		Port:   int64(dev.Port),
+	}
+
+	var hashes map[string]string
+	if a.source == "local" {
+		hashes, peer.Status, peer.Detail = a.localHashes(p, dev)
+	} else {
+		hashes, peer.Status, peer.Detail = a.replicateHashes(p, dev)
+		peer.ReplicationLast = a.replicationLast(dev)
+	}
+	if hashes == nil {
+		return peer
+	}
+
+	peer.NumSuffixes = int64(len(hashes))
+	for suffix, hash := range p.suffixHashes {
+		peerHash, ok := hashes[suffix]
+		switch {
+		case !ok:
+			peer.NumMissingSuffixes += 1
+		case peerHash == "":
+			// to be rehashed on the peer, not comparable yet
+		case peerHash != hash:
+			peer.NumMismatchedSuffixes += 1
+		}
+	}
+
+	switch {
+	case len(hashes) == 0 && len(p.suffixHashes) > 0:
+		peer.Status = replicaMissing
+	case peer.NumMissingSuffixes > 0 || peer.NumMismatchedSuffixes > 0:
+		peer.Status = replicaOutOfSync
+	default:
+		peer.Status = replicaInSync
+	}
+	return peer
+}
+
+// auditReplicas reports the health of the partition replicas on all peer
+// devices of the primary partition
+func (p *Partition) auditReplicas() {
+	// nothing to compare against without hashes.pkl indexed
+	if p.suffixHashes == nil {
+		logp.Debug("replica", "Skip replica audit without %s: %s", hashesFile, p.Path)
+		return
+	}
+
+	auditor := p.Resource.auditor
+	health := swift.ReplicaHealth{
+		Partition:   p.ToSwiftPartition(),
+		Source:      auditor.source,
+		NumSuffixes: int64(len(p.suffixHashes)),
+	}
+
+	for _, dev := range p.peers {
+		if p.stopped() {
+			return
+		}
+
+		peer := auditor.auditPeer(p, dev)
+		switch peer.Status {
+		case replicaInSync:
+			health.NumHealthy += 1
+		case replicaUnreachable, replicaError:
+			logp.Warn("replica audit of partition %d on %s/%s failed: %s",
+				p.PartId, peer.Ip, peer.Device, peer.Detail)
+			p.incrError(errReplica)
+		}
+		health.Peers = append(health.Peers, peer)
+	}
+
+	health.CheckedAt = time.Now()
+	p.publish(input.NewReplicaHealthEvent(health))
+}
@@ -0,0 +1,167 @@
+// +build !integration
+
+package indexer
+
+import (
+	"bytes"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	pickle "github.com/hydrogen18/stalecucumber"
+	"github.com/openstack/swift/go/hummingbird"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+const (
+	testHashA = "d41d8cd98f00b204e9800998ecf8427e"
+	testHashB = "0cc175b9c0f1b6a831c399e269772661"
+)
+
+// replicaHealth returns replica health events keyed by partition, and the
+// peers of each keyed by device
+func replicaHealth(events []common.MapStr) (map[int64]common.MapStr, map[int64]map[string]common.MapStr) {
+	health := map[int64]common.MapStr{}
+	peers := map[int64]map[string]common.MapStr{}
+	for _, event := range events {
+		partId := event["partition"].(int64)
+		health[partId] = event
+		peers[partId] = map[string]common.MapStr{}
+		for _, peer := range event["peers"].([]common.MapStr) {
+			peers[partId][peer["device"].(string)] = peer
+		}
+	}
+	return health, peers
+}
+
+func TestReplicaAuditLocal(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	local := map[string]interface{}{"abc": testHashA, "def": testHashB}
+	mkdev := func(dev string, part string) string {
+		dir := filepath.Join(node.swiftDir, "node", dev, "objects", part)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		return dir
+	}
+
+	// partition 0 is replicated to sdc and sdd, partition 2 to sdd and sde
+	// sdc is in sync, sdd is missing partition 0 and partition 2 is out of
+	// sync on it, sde is not mounted
+	writeHashes(t, mkdev("sdb", "0"), local)
+	writeHashes(t, mkdev("sdb", "2"), local)
+	writeHashes(t, mkdev("sdc", "0"), local)
+	writeHashes(t, mkdev("sdd", "2"), map[string]interface{}{"abc": testHashB, "def": nil})
+	// handoff partitions are not audited
+	writeHashes(t, mkdev("sdb", "1"), local)
+
+	events := node.scan(t, map[string]interface{}{
+		"enable_hashes_index":   true,
+		"enable_replica_audit":  true,
+		"replica_audit_source":  "local",
+		"replica_audit_devices": filepath.Join(node.swiftDir, "node"),
+	}, nil)
+
+	health, peers := replicaHealth(eventsOf(events, "replica_health"))
+	if !assert.Len(t, health, 2) {
+		return
+	}
+
+	assert.Equal(t, "local", health[0]["source"])
+	assert.Equal(t, int64(2), health[0]["num_suffixes"])
+	assert.Equal(t, int64(1), health[0]["num_healthy"])
+	assert.Equal(t, false, health[0]["healthy"])
+	assert.Equal(t, replicaInSync, peers[0]["sdc"]["status"])
+	assert.Equal(t, replicaMissing, peers[0]["sdd"]["status"])
+	assert.Equal(t, int64(2), peers[0]["sdd"]["num_missing_suffixes"])
+
+	assert.Equal(t, int64(0), health[2]["num_healthy"])
+	assert.Equal(t, replicaOutOfSync, peers[2]["sdd"]["status"])
+	assert.Equal(t, int64(1), peers[2]["sdd"]["num_mismatched_suffixes"])
+	assert.Equal(t, int64(0), peers[2]["sdd"]["num_missing_suffixes"])
+	assert.Equal(t, replicaUnmounted, peers[2]["sde"]["status"])
+}
+
+func TestReplicaAuditReplicate(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		requests = append(requests, req.Method+" "+req.URL.Path)
+		switch req.URL.Path {
+		case "/recon/replication/object":
+			w.Write([]byte(`{"object_replication_last": 1488413430.5}`))
+		case "/sdc/0":
+			assert.Equal(t, "REPLICATE", req.Method)
+			assert.Equal(t, "0", req.Header.Get("X-Backend-Storage-Policy-Index"))
+			var buf bytes.Buffer
+			pickle.NewPickler(&buf).Pickle(map[string]interface{}{"abc": testHashA})
+			w.Write(buf.Bytes())
+		case "/sde/3":
+			w.WriteHeader(http.StatusInsufficientStorage)
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer server.Close()
+
+	host, portStr, _ := net.SplitHostPort(server.Listener.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+
+	// a closed port for the unreachable host
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	// partition 0 is replicated to sdc and sdd, partition 3 to sde and sdc
+	// sdc and sde are served by the test server, sdd on the closed port
+	devs := []hummingbird.Device{
+		testDevs[0],
+		{Id: 1, Device: "sdc", Ip: "10.0.0.2", Port: 6000, ReplicationIp: host, ReplicationPort: port, Zone: 2},
+		{Id: 2, Device: "sdd", Ip: host, Port: closedPort, Zone: 3},
+		{Id: 3, Device: "sde", Ip: host, Port: port, Zone: 4},
+	}
+	writeTestRing(t, filepath.Join(node.swiftDir, "object.ring.gz"), devs, 3, 30, 0)
+
+	writeHashes(t, node.mkdir(t, "objects", "0"), map[string]interface{}{"abc": testHashA})
+	writeHashes(t, node.mkdir(t, "objects", "3"), map[string]interface{}{"abc": testHashA})
+
+	events := node.scan(t, map[string]interface{}{
+		"enable_hashes_index":   true,
+		"enable_replica_audit":  true,
+		"replica_audit_source":  "replicate",
+		"replica_audit_timeout": "1s",
+	}, nil)
+
+	health, peers := replicaHealth(eventsOf(events, "replica_health"))
+	if !assert.Len(t, health, 2) {
+		return
+	}
+	assert.Equal(t, "replicate", health[0]["source"])
+	assert.Equal(t, 2, health[0]["num_peers"])
+	assert.Equal(t, int64(1), health[0]["num_healthy"])
+	assert.Equal(t, replicaInSync, peers[0]["sdc"]["status"])
+	assert.Equal(t, replicaUnreachable, peers[0]["sdd"]["status"])
+	assert.Equal(t, replicaUnmounted, peers[3]["sde"]["status"])
+	assert.Equal(t, replicaError, peers[3]["sdc"]["status"])
+	assert.Contains(t, peers[3]["sde"], "replication_last")
+	assert.Contains(t, requests, "REPLICATE /sdc/0")
+
+	// failed peer requests are counted as errors of the scan
+	summaries := eventsOf(events, "scan_summary")
+	if assert.Len(t, summaries, 1) {
+		assert.Equal(t, common.MapStr{"replica": int64(2)}, summaries[0]["errors"])
+	}
+}
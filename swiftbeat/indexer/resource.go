@@ -1,13 +1,13 @@
 package indexer
 
 import (
-	"crypto/md5"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"net"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -15,23 +15,40 @@ import (
 	"github.com/openstack/swift/go/hummingbird"
 
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
 	"github.com/elastic/beats/swiftbeat/input/swift"
 )
 
+var (
+	ErrNotInRing = errors.New("device not found in ring")
+)
+
 // Resource is a generic modeling for all 3 types of resources
 type Resource struct {
 	*IndexRecord
 	*Disk
-	Type       string
-	sem        Semaphore
-	wg         sync.WaitGroup
-	partitions []*Partition
-	ring       hummingbird.Ring
-	RingMtime  time.Time
-	DevName    string
-	DevId      int
-	Ip         string
-	RingCKSum  string
+	Type        string
+	config      indexerConfig
+	PolicyIndex int64
+	PolicyName  string
+	PolicyType  string
+	ecFragments int64
+	wg          sync.WaitGroup
+	partWg      sync.WaitGroup
+	scanWg      sync.WaitGroup
+	stats       *scanStats
+	partitions  []*Partition
+	ring        *ringSnapshot
+	RingMtime   time.Time
+	DevName     string
+	DevId       int
+	Ip          string
+	RingCKSum   string
+	// handoff partitions found in current scan
+	handoffParts map[int64]handoffPart
+	handoffLock  sync.Mutex
+	// set if replica audit is enabled for the resource
+	auditor *replicaAuditor
 }
 
 func NewResource(
@@ -45,71 +62,140 @@ func NewResource(
 			Path:  filepath.Join(d.Path, file.Name()),
 			Mtime: file.ModTime(),
 		},
-		Disk:       d,
-		sem:        NewSemaphore(1),
-		partitions: nil,
-		DevName:    d.Name,
-		DevId:      -1,
+		Disk:         d,
+		partitions:   nil,
+		DevName:      d.Name,
+		DevId:        -1,
+		handoffParts: map[int64]handoffPart{},
+		stats:        newScanStats(),
 	}
-	// remove trailing 's' for resource type
-	res.Type = res.Name[:len(res.Name)-1]
+	resType, policy, err := parseResourceName(res.Name)
+	if err != nil {
+		return nil, err
+	}
+	res.Type = resType
+	res.PolicyIndex = policy
+
+	if policy, ok := d.swift.policies[int(res.PolicyIndex)]; ok {
+		res.PolicyName = policy.Name
+		res.PolicyType = policy.Type
+		res.ecFragments = ecFragments(policy.Config)
+	}
+
+	// settings of the disk with per resource type overrides applied
+	config, err := d.config.resolve(res.Type)
+	if err != nil {
+		return nil, err
+	}
+	res.config = config
+
+	if config.EnableReplicaAudit {
+		res.auditor = newReplicaAuditor(config)
+	}
+
 	res.wg.Add(1)
 	return res, nil
 }
 
-func (r *Resource) initRing() error {
-
-	// read cluster prefix / suffix from configuration file
-	hashPathPrefix, hashPathSuffix, err := hummingbird.GetHashPrefixAndSuffix()
+// ecFragments returns the number of fragments an EC object is split into,
+// or 0 if not configured for the policy
+func ecFragments(config map[string]string) int64 {
+	data, err := strconv.ParseInt(config["ec_num_data_fragments"], 10, 64)
 	if err != nil {
-		logp.Err("Error getting Swift hash prefix and suffix")
-		return err
+		return 0
+	}
+	parity, err := strconv.ParseInt(config["ec_num_parity_fragments"], 10, 64)
+	if err != nil {
+		return 0
+	}
+	return data + parity
+}
+
+// parseResourceName returns the resource type and storage policy of a
+// resource dir, dirs for non-default policies are named like objects-N
+func parseResourceName(name string) (string, int64, error) {
+	resName := name
+	policy := int64(0)
+	if i := strings.Index(resName, "-"); i >= 0 {
+		var err error
+		policy, err = strconv.ParseInt(resName[i+1:], 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid policy index in dir name: %s", name)
+		}
+		resName = resName[:i]
 	}
 
+	// remove trailing 's' for resource type
+	return strings.TrimSuffix(resName, "s"), policy, nil
+}
+
+func (r *Resource) initRing() error {
+	// cluster prefix / suffix loaded from configuration file by disk
+	hashPathPrefix := r.Disk.swift.hashPathPrefix
+	hashPathSuffix := r.Disk.swift.hashPathSuffix
+
 	logp.Debug("resource", "Swift hash prefix, suffix: %s %s", hashPathPrefix, hashPathSuffix)
 
-	// initialize ring for the resource type
-	// TODO: add multi policy support
-	ring, err := hummingbird.GetRingSnapshot(r.Type, hashPathPrefix, hashPathSuffix, 0)
+	// initialize ring for the resource type and storage policy
+	ringPath := r.Disk.swiftConf.ringPath(r.Type, r.PolicyIndex)
+	ring, err := loadRing(ringPath, hashPathPrefix, hashPathSuffix)
 	if err != nil {
-		logp.Err("Error reading the %s ring", r.Type)
+		logp.Err("Error reading the %s ring: %s", r.Type, ringPath)
 		return err
 	}
 	r.ring = ring
 	r.initDevInfo()
 
-	// probe ring mtime since it is not exposed in Hummingbird
-	ringPath := fmt.Sprintf("/etc/swift/%s.ring.gz", r.Type)
-	if f, err := os.Stat(ringPath); err == nil {
-		r.RingMtime = f.ModTime()
+	// mtime and checksum describe the very content of the ring snapshot
+	r.RingMtime = ring.mtime
+	r.RingCKSum = ring.cksum
+
+	return nil
+}
+
+// helper function to return the IPs the device is looked up with in the ring
+func (r *Resource) bindIps() []string {
+	if r.config.BindIp != "" {
+		return []string{r.config.BindIp}
 	}
 
-	data, err := ioutil.ReadFile(ringPath)
+	var ips []string
+	localAddrs, err := net.InterfaceAddrs()
 	if err != nil {
-		logp.Err("Error getting checksum for the ring: %s", ringPath)
-		return err
+		return nil
+	}
+	for _, addr := range localAddrs {
+		ips = append(ips, strings.Split(addr.String(), "/")[0])
 	}
-	r.RingCKSum = fmt.Sprintf("%x", md5.Sum(data))
+	return ips
+}
 
-	return nil
+// helper function to tell whether the ring device is served on the ip and
+// port, the replication network is considered as well
+func (r *Resource) isBindDevice(dev hummingbird.Device, ips map[string]bool) bool {
+	port := r.config.BindPort
+	if ips[dev.Ip] && (port == 0 || dev.Port == port) {
+		return true
+	}
+	if ips[dev.ReplicationIp] && (port == 0 || dev.ReplicationPort == port) {
+		return true
+	}
+	return false
 }
 
 // init Dev Id and IP based on ring lookup with local IP and device name
 func (r *Resource) initDevInfo() {
+	r.DevId = -1
+	r.Ip = ""
 
-	var localIPs = make(map[string]bool)
-
-	localAddrs, err := net.InterfaceAddrs()
-	if err != nil {
-		return
-	}
-	for _, addr := range localAddrs {
-		localIPs[strings.Split(addr.String(), "/")[0]] = true
+	ips := make(map[string]bool)
+	for _, ip := range r.bindIps() {
+		ips[ip] = true
 	}
 
 	devs := r.ring.AllDevices()
 	for _, dev := range devs {
-		if localIPs[dev.Ip] && dev.Device == r.DevName {
+		if dev.Device == r.DevName && r.isBindDevice(dev, ips) {
 			r.DevId = dev.Id
 			r.Ip = dev.Ip
 			break
@@ -117,6 +203,22 @@ func (r *Resource) initDevInfo() {
 	}
 }
 
+// notInRing returns the event reporting the device not found in the ring
+func (r *Resource) notInRing() input.Event {
+	return input.NewDeviceNotInRingEvent(swift.DeviceNotInRing{
+		ResourceType: r.Type,
+		PolicyIndex:  r.PolicyIndex,
+		PolicyName:   r.PolicyName,
+		Device:       r.DevName,
+		BindIps:      r.bindIps(),
+		BindPort:     int64(r.config.BindPort),
+		RingMtime:    r.RingMtime,
+		RingCKSum:    r.RingCKSum,
+		Skipped:      r.config.NotInRing == "skip",
+		DetectedAt:   time.Now(),
+	})
+}
+
 func (r *Resource) init() error {
 	defer r.wg.Done()
 
@@ -126,13 +228,30 @@ func (r *Resource) init() error {
 	err := r.initRing()
 	if err != nil {
 		logp.Err("Failed to init ring data")
+		r.incrError(errRing)
 		return err
 	}
 
+	// every partition would be taken as handoff without the device in ring
+	// it is reported once when the device goes missing from the ring
+	if r.DevId < 0 {
+		logp.Warn("Device %s not found in the %s ring: %s",
+			r.DevName, r.Type, r.swiftConf.ringPath(r.Type, r.PolicyIndex))
+		if r.Disk.setNotInRing(r, true) {
+			r.publish(r.notInRing())
+		}
+		if r.config.NotInRing == "skip" {
+			return ErrNotInRing
+		}
+	} else {
+		r.Disk.setNotInRing(r, false)
+	}
+
 	// load partitions for the resource type
-	files, err := ioutil.ReadDir(path)
+	files, err := readDir(r.limiter, path)
 	if err != nil {
 		logp.Err("list dir(%s) failed: %v", path, err)
+		r.incrError(errListDir)
 		return err
 	}
 
@@ -143,6 +262,9 @@ func (r *Resource) init() error {
 		}
 
 		part, _ := NewPartition(r, file)
+		if !r.Disk.config.scanPartition(part.PartId) {
+			continue
+		}
 		parts = append(parts, part)
 	}
 
@@ -156,18 +278,85 @@ func (r *Resource) init() error {
 // it happens depends on the concurrency settings
 func (r *Resource) BuildIndex() {
 	logp.Debug("resource", "Start building index for resource: %s", r.Name)
+	r.scanStarted()
 
 	// load partition list for the resource
+	// the scan still gets summarized so failures are reported
 	err := r.init()
 	if err != nil {
+		r.scanFinished()
+		if !r.stopped() {
+			r.publish(r.scanSummary())
+		}
 		return
 	}
 
-	// number of partition indexer can run simulataneously
-	// is controlled by resource level semaphore
-	for _, part := range r.partitions {
-		go part.BuildIndex()
+	// partition assignment moved if ring changed since last scan
+	if event := r.checkRingChange(); event != nil {
+		r.publish(event)
+	}
+
+	// partitions are fed to a bounded pool of workers, number of partition
+	// indexers can run simultaneously is further controlled by disk and
+	// global level semaphores
+	workers := r.Disk.config.PartitionWorkers
+	if workers > len(r.partitions) {
+		workers = len(r.partitions)
 	}
+
+	parts := make(chan *Partition)
+	for i := 0; i < workers; i++ {
+		r.partWg.Add(1)
+		go func() {
+			defer r.partWg.Done()
+			for p := range parts {
+				p.BuildIndex()
+			}
+		}()
+	}
+
+	// feeding stops once the scan is cancelled so workers can drain and exit
+	go func() {
+		defer close(parts)
+		for _, part := range r.partitions {
+			select {
+			case <-r.done:
+				return
+			case parts <- part:
+			}
+		}
+	}()
+
+	finished := make(chan struct{})
+	if interval := r.Disk.config.ScanSummaryInterval; interval > 0 {
+		go r.reportProgress(interval, finished)
+	}
+
+	// summarize the scan once all partitions are indexed
+	// stats of a cancelled scan are partial and dropped
+	r.scanWg.Add(1)
+	go func() {
+		defer r.scanWg.Done()
+		r.partWg.Wait()
+		close(finished)
+		r.scanFinished()
+
+		if r.stopped() {
+			return
+		}
+		r.Disk.recordPartitions(r)
+		if r.Type == "object" {
+			r.publish(r.handoffSummary())
+		}
+		r.publish(r.scanSummary())
+	}()
+}
+
+// Wait blocks until all partition indexers started by BuildIndex finish
+// and the scan is summarized
+func (r *Resource) Wait() {
+	r.partWg.Wait()
+	r.scanWg.Wait()
 }
 
 // AnnotateSwiftObject add info from indexer to the swift.Object data object
@@ -176,5 +365,5 @@ func (r *Resource) AnnotateSwiftObject(obj *swift.Object) {
 		logp.Critical("AnnotateSwiftObject: BUG: disk reference is nil")
 	}
 	r.Disk.AnnotateSwiftObject(obj)
-	obj.Annotate(*r)
+	obj.Annotate(r)
 }
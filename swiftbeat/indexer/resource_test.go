@@ -0,0 +1,158 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/openstack/swift/go/hummingbird"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+func TestParseResourceName(t *testing.T) {
+	tests := []struct {
+		name    string
+		resType string
+		policy  int64
+		err     bool
+	}{
+		{name: "accounts", resType: "account"},
+		{name: "containers", resType: "container"},
+		{name: "objects", resType: "object"},
+		{name: "objects-2", resType: "object", policy: 2},
+		{name: "async_pending-1", resType: "async_pending", policy: 1},
+		{name: "objects-x", err: true},
+	}
+
+	for _, test := range tests {
+		resType, policy, err := parseResourceName(test.name)
+		if test.err {
+			assert.Error(t, err, test.name)
+			continue
+		}
+		assert.NoError(t, err, test.name)
+		assert.Equal(t, test.resType, resType, test.name)
+		assert.Equal(t, test.policy, policy, test.name)
+	}
+}
+
+func TestIsBindDevice(t *testing.T) {
+	dev := hummingbird.Device{
+		Device: "sdb", Ip: "10.0.0.1", Port: 6000,
+		ReplicationIp: "10.1.0.1", ReplicationPort: 6010,
+	}
+
+	tests := []struct {
+		name  string
+		ip    string
+		port  int
+		match bool
+	}{
+		{name: "ip", ip: "10.0.0.1", match: true},
+		{name: "ip and port", ip: "10.0.0.1", port: 6000, match: true},
+		{name: "ip with other port", ip: "10.0.0.1", port: 6001},
+		{name: "replication ip", ip: "10.1.0.1", match: true},
+		{name: "replication ip and port", ip: "10.1.0.1", port: 6010, match: true},
+		{name: "replication ip with service port", ip: "10.1.0.1", port: 6000},
+		{name: "other ip", ip: "10.0.0.2"},
+	}
+
+	for _, test := range tests {
+		r := &Resource{config: indexerConfig{BindIp: test.ip, BindPort: test.port}}
+		assert.Equal(t, test.match, r.isBindDevice(dev, map[string]bool{test.ip: true}), test.name)
+	}
+}
+
+func TestNotInRing(t *testing.T) {
+	tests := []struct {
+		policy  string
+		skipped bool
+	}{
+		{policy: "index"},
+		{policy: "skip", skipped: true},
+	}
+
+	for _, test := range tests {
+		node := newTestNode(t)
+		for _, part := range []string{"0", "1", "2", "3"} {
+			node.mkdir(t, "objects", part)
+		}
+
+		// sdb is served on another ip than the one bound to
+		eventChan := make(chan input.Event, 100)
+		disk := node.newDisk(t, map[string]interface{}{
+			"bind_ip":     "10.9.9.9",
+			"not_in_ring": test.policy,
+		}, nil, eventChan, make(chan struct{}))
+
+		// scan returns the events of one scan of the disk
+		scan := func() []input.Event {
+			buildIndex(t, disk)
+			close(eventChan)
+			var events []input.Event
+			for event := range eventChan {
+				events = append(events, event)
+			}
+			eventChan = make(chan input.Event, 100)
+			disk.eventChan = eventChan
+			return events
+		}
+
+		events := scan()
+		missing := eventsOf(events, "device_not_in_ring")
+		if assert.Len(t, missing, 1, test.policy) {
+			assert.Equal(t, "object", missing[0]["resource_type"], test.policy)
+			assert.Equal(t, []string{"10.9.9.9"}, missing[0]["bind_ips"], test.policy)
+			assert.Equal(t, test.skipped, missing[0]["skipped"], test.policy)
+		}
+
+		parts := eventsOf(events, "obj_partition")
+		if test.skipped {
+			assert.Len(t, parts, 0, test.policy)
+		} else {
+			// every partition is taken as handoff
+			assert.Len(t, parts, 4, test.policy)
+			for _, part := range parts {
+				assert.Equal(t, true, part["handoff"], test.policy)
+			}
+		}
+
+		// the missing device is reported once
+		events = scan()
+		assert.Len(t, eventsOf(events, "device_not_in_ring"), 0, test.policy)
+
+		// and again once it is missing after being found in the ring
+		disk.config.BindIp = "127.0.0.1"
+		scan()
+		disk.config.BindIp = "10.9.9.9"
+		events = scan()
+		assert.Len(t, eventsOf(events, "device_not_in_ring"), 1, test.policy)
+
+		os.RemoveAll(node.swiftDir)
+	}
+}
+
+func TestNotInRingPolicyRing(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	// sdb is only left out of the ring of policy 1
+	writeTestRing(t, filepath.Join(node.swiftDir, "object-1.ring.gz"), testDevs[1:], 3, 30, 0)
+	node.mkdir(t, "objects", "0")
+	node.mkdir(t, "objects-1", "0")
+
+	events := node.scan(t, map[string]interface{}{"not_in_ring": "skip"}, nil)
+
+	missing := eventsOf(events, "device_not_in_ring")
+	if assert.Len(t, missing, 1) {
+		assert.Equal(t, int64(1), missing[0]["policy_index"])
+	}
+	parts := eventsOf(events, "obj_partition")
+	if assert.Len(t, parts, 1) {
+		assert.Equal(t, int64(0), parts[0]["policy_index"])
+	}
+}
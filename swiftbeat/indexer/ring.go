@@ -0,0 +1,139 @@
+package indexer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openstack/swift/go/hummingbird"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// ringState keeps the ring seen by the last scan of a resource
+// to detect ring changes between scans
+type ringState struct {
+	ring      *ringSnapshot
+	devId     int
+	ringMtime time.Time
+	ringCKSum string
+}
+
+// helper function to return the ring state key of a resource
+func ringKey(resType string, policy int64) string {
+	return fmt.Sprintf("%s-%d", resType, policy)
+}
+
+// assignment returns the replica index of the device for a partition
+// -1 means partition is not assigned to the device and -2 means partition
+// is out of ring range
+func assignment(ring *ringSnapshot, partId uint64, devId int) (int, []*hummingbird.Device) {
+	nodes := ring.GetNodesInOrder(partId)
+	if nodes == nil {
+		return -2, nil
+	}
+
+	for i, n := range nodes {
+		if n.Id == devId {
+			return i, nodes
+		}
+	}
+	return -1, nodes
+}
+
+// helper function to compare peer devices of a partition in two rings
+func samePeers(a, b []*hummingbird.Device) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Id != b[i].Id {
+			return false
+		}
+	}
+	return true
+}
+
+// diffRings walks all partitions and returns those whose assignment on the
+// device changed between the previous and the current ring
+func diffRings(prev *ringState, cur *ringState) (movedIn, movedOut, reassigned []int64) {
+	for partId := uint64(0); ; partId++ {
+		curReplica, curNodes := assignment(cur.ring, partId, cur.devId)
+		prevReplica, prevNodes := assignment(prev.ring, partId, prev.devId)
+
+		// partition out of range for both rings
+		if curReplica == -2 && prevReplica == -2 {
+			break
+		}
+
+		switch {
+		case prevReplica < 0 && curReplica >= 0:
+			movedIn = append(movedIn, int64(partId))
+		case prevReplica >= 0 && curReplica < 0:
+			movedOut = append(movedOut, int64(partId))
+		case prevReplica >= 0 && curReplica >= 0:
+			if prevReplica != curReplica || !samePeers(prevNodes, curNodes) {
+				reassigned = append(reassigned, int64(partId))
+			}
+		}
+	}
+	return
+}
+
+// checkRingChange compares the ring loaded by the resource against the one
+// seen in the previous scan and returns the ring change event if any
+func (r *Resource) checkRingChange() input.Event {
+	cur := &ringState{
+		ring:      r.ring,
+		devId:     r.DevId,
+		ringMtime: r.RingMtime,
+		ringCKSum: r.RingCKSum,
+	}
+
+	key := ringKey(r.Type, r.PolicyIndex)
+
+	r.Disk.ringsLock.Lock()
+	prev, found := r.Disk.rings[key]
+	r.Disk.rings[key] = cur
+	r.Disk.ringsLock.Unlock()
+
+	if !found || prev.ringCKSum == cur.ringCKSum {
+		return nil
+	}
+
+	logp.Info("Ring changed for %s policy %d on %s: %s -> %s",
+		r.Type, r.PolicyIndex, r.DevName, prev.ringCKSum, cur.ringCKSum)
+
+	movedIn, movedOut, reassigned := diffRings(prev, cur)
+
+	change := swift.RingChange{
+		ResourceType:    r.Type,
+		PolicyIndex:     r.PolicyIndex,
+		PolicyName:      r.PolicyName,
+		Device:          r.DevName,
+		Ip:              r.Ip,
+		DetectedAt:      time.Now(),
+		RingMtime:       cur.ringMtime,
+		RingCKSum:       cur.ringCKSum,
+		PrevRingMtime:   prev.ringMtime,
+		PrevRingCKSum:   prev.ringCKSum,
+		PartsMovedIn:    movedIn,
+		PartsMovedOut:   movedOut,
+		PartsReassigned: reassigned,
+	}
+	return input.NewRingChangedEvent(change)
+}
+
+// setNotInRing records whether the device is missing from the ring of the
+// resource and tells whether it changed since the previous scan
+func (d *Disk) setNotInRing(r *Resource, missing bool) bool {
+	key := ringKey(r.Type, r.PolicyIndex)
+
+	d.ringsLock.Lock()
+	defer d.ringsLock.Unlock()
+
+	changed := d.notInRing[key] != missing
+	d.notInRing[key] = missing
+	return changed
+}
@@ -0,0 +1,135 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openstack/swift/go/hummingbird"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+func TestCheckRingChange(t *testing.T) {
+	dir := testSwiftDir(t)
+	defer os.RemoveAll(dir)
+
+	disk := &Disk{
+		IndexRecord: &IndexRecord{Name: "sdb"},
+		swiftConf:   SwiftConfig{SwiftDir: dir},
+		swift:       &swiftInfo{hashPathPrefix: "pre", hashPathSuffix: "suf"},
+		rings:       map[string]*ringState{},
+	}
+	path := disk.swiftConf.ringPath("object", 0)
+	mtime := time.Now().Add(-time.Hour)
+
+	// each scan loads the ring file as rewritten by the previous step
+	scan := func(devs []hummingbird.Device, rot int) *swift.RingChange {
+		writeTestRing(t, path, devs, 3, 30, rot)
+		mtime = mtime.Add(time.Minute)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+
+		res := &Resource{
+			Disk:    disk,
+			Type:    "object",
+			config:  indexerConfig{BindIp: "127.0.0.1"},
+			DevName: "sdb",
+		}
+		if err := res.initRing(); err != nil {
+			t.Fatal(err)
+		}
+		event := res.checkRingChange()
+		if event == nil {
+			return nil
+		}
+		return &event.(*input.RingChangedEvent).Change
+	}
+
+	// 4 partitions of 3 replicas, the assignment of sdb with rot 0 is p0 r0,
+	// p2 r2, p3 r1 and with rot 1 is p1 r2, p2 r1, p3 r0
+	tests := []struct {
+		name       string
+		devs       []hummingbird.Device
+		rot        int
+		changed    bool
+		movedIn    []int64
+		movedOut   []int64
+		reassigned []int64
+	}{
+		{name: "first scan", devs: testDevs},
+		{name: "same ring rewritten", devs: testDevs},
+		{
+			name:       "rebalanced",
+			devs:       testDevs,
+			rot:        1,
+			changed:    true,
+			movedIn:    []int64{1},
+			movedOut:   []int64{0},
+			reassigned: []int64{2, 3},
+		},
+		{
+			name:     "device removed",
+			devs:     testDevs[1:],
+			rot:      1,
+			changed:  true,
+			movedOut: []int64{1, 2, 3},
+		},
+		{
+			name:    "device added",
+			devs:    testDevs,
+			rot:     1,
+			changed: true,
+			movedIn: []int64{1, 2, 3},
+		},
+	}
+
+	for _, test := range tests {
+		change := scan(test.devs, test.rot)
+		if !test.changed {
+			assert.Nil(t, change, test.name)
+			continue
+		}
+		if !assert.NotNil(t, change, test.name) {
+			continue
+		}
+		assert.NotEqual(t, change.PrevRingCKSum, change.RingCKSum, test.name)
+		assert.Equal(t, test.movedIn, change.PartsMovedIn, test.name)
+		assert.Equal(t, test.movedOut, change.PartsMovedOut, test.name)
+		assert.Equal(t, test.reassigned, change.PartsReassigned, test.name)
+	}
+}
+
+func TestAssignment(t *testing.T) {
+	dir := testSwiftDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "object.ring.gz")
+	writeTestRing(t, path, testDevs, 3, 30, 0)
+	ring, err := loadRing(path, "pre", "suf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		partId  uint64
+		devId   int
+		replica int
+	}{
+		{0, 0, 0},
+		{0, 2, 2},
+		{1, 0, -1},
+		{3, 0, 1},
+		{4, 0, -2},
+	}
+	for _, test := range tests {
+		replica, _ := assignment(ring, test.partId, test.devId)
+		assert.Equal(t, test.replica, replica, "partition %d device %d", test.partId, test.devId)
+	}
+}
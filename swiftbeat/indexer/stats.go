@@ -0,0 +1,158 @@
+package indexer
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// counters tracked per disk and resource type
+const (
+	statPartitions        = "partitions"
+	statPartitionsSkipped = "partitions_skipped"
+	statSuffixes          = "suffixes"
+	statHashes            = "hashes"
+	statFiles             = "files"
+)
+
+// error types tracked per disk and resource type
+const (
+	errRing     = "ring"
+	errListDir  = "list_dir"
+	errHashes   = "hashes"
+	errMetadata = "metadata"
+	errDB       = "db"
+	errReplica  = "replica"
+)
+
+var (
+	// cumulative counters keyed as <device>.<resource>.<counter>
+	indexerStats = expvar.NewMap("indexer")
+)
+
+// scanStats keeps the counters of the current scan of a resource
+type scanStats struct {
+	startedAt  time.Time
+	finishedAt time.Time
+	counters   map[string]int64
+	errors     map[string]int64
+	lock       sync.Mutex
+}
+
+func newScanStats() *scanStats {
+	return &scanStats{
+		startedAt: time.Now(),
+		counters:  map[string]int64{},
+		errors:    map[string]int64{},
+	}
+}
+
+// helper function to return the expvar key of a resource counter
+func (r *Resource) statsKey(name string) string {
+	resName := r.Type
+	if r.PolicyIndex != 0 {
+		resName = fmt.Sprintf("%s-%d", r.Type, r.PolicyIndex)
+	}
+	return fmt.Sprintf("%s.%s.%s", r.DevName, resName, name)
+}
+
+// incr adds one to the counter of the current scan
+func (r *Resource) incr(name string) {
+	r.stats.lock.Lock()
+	r.stats.counters[name] += 1
+	r.stats.lock.Unlock()
+
+	indexerStats.Add(r.statsKey(name), 1)
+}
+
+// incrError adds one to the error counter of the current scan
+func (r *Resource) incrError(kind string) {
+	r.stats.lock.Lock()
+	r.stats.errors[kind] += 1
+	r.stats.lock.Unlock()
+
+	indexerStats.Add(r.statsKey("errors."+kind), 1)
+}
+
+// scanStarted resets the counters for a new scan
+func (r *Resource) scanStarted() {
+	r.stats = newScanStats()
+
+	started := new(expvar.Int)
+	started.Set(r.stats.startedAt.Unix())
+	indexerStats.Set(r.statsKey("scan_started"), started)
+}
+
+// scanFinished records the end of the current scan
+func (r *Resource) scanFinished() {
+	r.stats.lock.Lock()
+	r.stats.finishedAt = time.Now()
+	duration := r.stats.finishedAt.Sub(r.stats.startedAt)
+	r.stats.lock.Unlock()
+
+	finished := new(expvar.Int)
+	finished.Set(r.stats.finishedAt.Unix())
+	indexerStats.Set(r.statsKey("scan_finished"), finished)
+
+	durationMs := new(expvar.Int)
+	durationMs.Set(int64(duration / time.Millisecond))
+	indexerStats.Set(r.statsKey("scan_duration_ms"), durationMs)
+}
+
+// scanSummary returns the scan summary event of the current scan, it can be
+// called while the scan is still in progress
+func (r *Resource) scanSummary() input.Event {
+	r.stats.lock.Lock()
+	defer r.stats.lock.Unlock()
+
+	now := time.Now()
+	summary := swift.ScanSummary{
+		ResourceType:   r.Type,
+		PolicyIndex:    r.PolicyIndex,
+		PolicyName:     r.PolicyName,
+		Device:         r.DevName,
+		Ip:             r.Ip,
+		ReportedAt:     now,
+		StartedAt:      r.stats.startedAt,
+		FinishedAt:     r.stats.finishedAt,
+		Finished:       !r.stats.finishedAt.IsZero(),
+		NumParts:       int64(len(r.partitions)),
+		PartsScanned:   r.stats.counters[statPartitions],
+		PartsSkipped:   r.stats.counters[statPartitionsSkipped],
+		SuffixesWalked: r.stats.counters[statSuffixes],
+		HashesWalked:   r.stats.counters[statHashes],
+		FilesIndexed:   r.stats.counters[statFiles],
+		Errors:         map[string]int64{},
+		DurationMs:     int64(now.Sub(r.stats.startedAt) / time.Millisecond),
+	}
+	if summary.Finished {
+		summary.DurationMs = int64(r.stats.finishedAt.Sub(r.stats.startedAt) / time.Millisecond)
+	}
+	for kind, count := range r.stats.errors {
+		summary.Errors[kind] = count
+	}
+
+	return input.NewScanSummaryEvent(summary)
+}
+
+// reportProgress publishes in-progress scan summaries periodically until the
+// scan finishes, so slow or stuck scans can be told apart
+func (r *Resource) reportProgress(interval time.Duration, finished chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case <-finished:
+			return
+		case <-ticker.C:
+			r.publish(r.scanSummary())
+		}
+	}
+}
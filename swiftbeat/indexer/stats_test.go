@@ -0,0 +1,80 @@
+// +build !integration
+
+package indexer
+
+import (
+	"expvar"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestScanSummary(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	// two objects in partition 0, one without metadata in partition 2 and
+	// an empty partition 3
+	for i, partId := range []uint64{0, 0, 2} {
+		name := node.objectName(t, partId)
+		if i == 1 {
+			name = name + "-2"
+		}
+		path := filepath.Join(node.hashDir(t, "objects", name), "1488413430.12345.data")
+		writeFile(t, path, 0)
+		if i < 2 {
+			writeMetadata(t, path, map[string]string{"name": name, "X-Timestamp": "1488413430.12345"}, 0)
+		}
+	}
+	node.mkdir(t, "objects", "3")
+	// objects of policy 1 can't be indexed without a ring
+	node.mkdir(t, "objects-1", "0")
+	os.Remove(filepath.Join(node.swiftDir, "object-1.ring.gz"))
+
+	// expvar counters are cumulative across scans
+	counter := func(key string) int64 {
+		if v, ok := indexerStats.Get(key).(*expvar.Int); ok {
+			return v.Value()
+		}
+		return 0
+	}
+	hashes, ringErrors := counter("sdb.object.hashes"), counter("sdb.object-1.errors.ring")
+
+	events := node.scan(t, map[string]interface{}{
+		"partition_index_only":  false,
+		"enable_datafile_index": true,
+	}, nil)
+
+	summaries := map[int64]common.MapStr{}
+	for _, summary := range eventsOf(events, "scan_summary") {
+		summaries[summary["policy_index"].(int64)] = summary
+	}
+	if !assert.Len(t, summaries, 2) {
+		return
+	}
+
+	summary := summaries[0]
+	assert.Equal(t, "object", summary["resource_type"])
+	assert.Equal(t, "sdb", summary["device"])
+	assert.Equal(t, true, summary["finished"])
+	assert.Contains(t, summary, "finished_at")
+	assert.Equal(t, int64(3), summary["num_parts"])
+	assert.Equal(t, int64(3), summary["parts_scanned"])
+	assert.Equal(t, int64(0), summary["parts_skipped"])
+	assert.Equal(t, int64(3), summary["hashes_walked"])
+	assert.Equal(t, int64(3), summary["files_indexed"])
+	assert.Equal(t, common.MapStr{"metadata": int64(1)}, summary["errors"])
+
+	// failed scans are summarized as well
+	summary = summaries[1]
+	assert.Equal(t, true, summary["finished"])
+	assert.Equal(t, int64(0), summary["num_parts"])
+	assert.Equal(t, common.MapStr{"ring": int64(1)}, summary["errors"])
+
+	assert.Equal(t, hashes+3, counter("sdb.object.hashes"))
+	assert.Equal(t, ringErrors+1, counter("sdb.object-1.errors.ring"))
+}
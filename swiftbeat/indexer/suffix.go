@@ -1,7 +1,6 @@
 package indexer
 
 import (
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
@@ -52,9 +51,10 @@ func (s *Suffix) init() error {
 
 	var hashes HashSorter
 
-	files, err := ioutil.ReadDir(path)
+	files, err := readDir(s.limiter, path)
 	if err != nil {
 		logp.Err("list dir(%s) failed: %v", path, err)
+		s.incrError(errListDir)
 		return err
 	}
 
@@ -83,8 +83,12 @@ func (s *Suffix) BuildIndex() {
 	if err != nil {
 		return
 	}
+	s.incr(statSuffixes)
 
 	for _, hash := range s.hashes {
+		if s.stopped() {
+			return
+		}
 		hash.BuildIndex()
 	}
 }
@@ -95,5 +99,5 @@ func (s *Suffix) AnnotateSwiftObject(obj *swift.Object) {
 		logp.Critical("AnnotateSwiftObject: BUG: partition reference is nil")
 	}
 	s.Partition.AnnotateSwiftObject(obj)
-	obj.Annotate(*s)
+	obj.Annotate(s)
 }
@@ -0,0 +1,300 @@
+package indexer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openstack/swift/go/hummingbird"
+)
+
+var (
+	DefaultSwiftConfig = SwiftConfig{
+		SwiftDir: "/etc/swift",
+	}
+
+	// ring snapshots loaded per ring file, hash path prefix and suffix
+	rings     = map[string]*ringSnapshot{}
+	ringsLock sync.Mutex
+)
+
+// SwiftConfig locates the Swift cluster configuration used by the indexer
+// ring_dir and swift_conf default to locations under swift_dir when not set
+type SwiftConfig struct {
+	SwiftDir  string `config:"swift_dir"`
+	RingDir   string `config:"ring_dir"`
+	SwiftConf string `config:"swift_conf"`
+}
+
+func (c SwiftConfig) ringDir() string {
+	if c.RingDir != "" {
+		return c.RingDir
+	}
+	return c.SwiftDir
+}
+
+func (c SwiftConfig) swiftConf() string {
+	if c.SwiftConf != "" {
+		return c.SwiftConf
+	}
+	return filepath.Join(c.SwiftDir, "swift.conf")
+}
+
+// ringPath returns the ring file path for the resource type and policy
+func (c SwiftConfig) ringPath(resType string, policy int64) string {
+	ringFile := fmt.Sprintf("%s.ring.gz", resType)
+	if policy != 0 {
+		ringFile = fmt.Sprintf("%s-%d.ring.gz", resType, policy)
+	}
+	return filepath.Join(c.ringDir(), ringFile)
+}
+
+// swiftInfo holds cluster wide settings loaded from swift.conf
+type swiftInfo struct {
+	hashPathPrefix string
+	hashPathSuffix string
+	policies       hummingbird.PolicyList
+}
+
+func loadSwiftInfo(c SwiftConfig) (*swiftInfo, error) {
+	conf, err := hummingbird.LoadConfig(c.swiftConf())
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, _ := conf.Get("swift-hash", "swift_hash_path_prefix")
+	suffix, ok := conf.Get("swift-hash", "swift_hash_path_suffix")
+	if !ok {
+		return nil, errors.New("Hash path suffix not defined")
+	}
+
+	info := &swiftInfo{
+		hashPathPrefix: prefix,
+		hashPathSuffix: suffix,
+		policies:       loadPolicies(conf),
+	}
+	return info, nil
+}
+
+// loadPolicies returns the storage policies defined in swift.conf, policy 0
+// is implied and is the default unless another policy is marked as default
+func loadPolicies(conf hummingbird.Config) hummingbird.PolicyList {
+	policies := hummingbird.PolicyList{0: &hummingbird.Policy{
+		Index: 0,
+		Type:  "replication",
+		Name:  "Policy-0",
+	}}
+	for key := range conf.File {
+		var policyIndex int
+		if c, err := fmt.Sscanf(key, "storage-policy:%d", &policyIndex); err != nil || c != 1 {
+			continue
+		}
+
+		aliases := []string{}
+		for _, alias := range strings.Split(conf.GetDefault(key, "aliases", ""), ",") {
+			if alias = strings.TrimSpace(alias); alias != "" {
+				aliases = append(aliases, alias)
+			}
+		}
+		policies[policyIndex] = &hummingbird.Policy{
+			Index:      policyIndex,
+			Type:       conf.GetDefault(key, "policy_type", "replication"),
+			Name:       conf.GetDefault(key, "name", fmt.Sprintf("Policy-%d", policyIndex)),
+			Aliases:    aliases,
+			Deprecated: conf.GetBool(key, "deprecated", false),
+			Default:    conf.GetBool(key, "default", false),
+			Config:     map[string]string(conf.File[key]),
+		}
+	}
+
+	defaultFound := false
+	for _, policy := range policies {
+		if policy.Default {
+			defaultFound = true
+		}
+	}
+	if !defaultFound {
+		policies[0].Default = true
+	}
+	return policies
+}
+
+// ringSnapshot is the content of a ring file at the time it was loaded.
+// Unlike rings of hummingbird.LoadRing it is never reloaded, so a scan and the
+// ring change detection see one consistent ring
+type ringSnapshot struct {
+	devs               []hummingbird.Device
+	replicaCount       int
+	partShift          uint64
+	replica2part2devId [][]uint16
+	prefix             string
+	suffix             string
+	mtime              time.Time
+	size               int64
+	cksum              string
+}
+
+// parseRing decodes the gzipped ring file format shared by Swift and
+// Hummingbird
+func parseRing(data []byte) (*ringSnapshot, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+
+	magic := make([]byte, 4)
+	if _, err := io.ReadFull(gz, magic); err != nil || string(magic) != "R1NG" {
+		return nil, errors.New("bad magic string")
+	}
+	var version uint16
+	if err := binary.Read(gz, binary.BigEndian, &version); err != nil {
+		return nil, err
+	}
+	if version != 1 {
+		return nil, fmt.Errorf("unknown ring version %d", version)
+	}
+
+	var metaLen uint32
+	if err := binary.Read(gz, binary.BigEndian, &metaLen); err != nil {
+		return nil, err
+	}
+	meta := make([]byte, metaLen)
+	if _, err := io.ReadFull(gz, meta); err != nil {
+		return nil, err
+	}
+	var header struct {
+		Devs         []hummingbird.Device `json:"devs"`
+		ReplicaCount int                  `json:"replica_count"`
+		PartShift    uint64               `json:"part_shift"`
+	}
+	if err := json.Unmarshal(meta, &header); err != nil {
+		return nil, err
+	}
+	if header.ReplicaCount <= 0 || header.PartShift > 32 {
+		return nil, fmt.Errorf("invalid ring replica count %d or part shift %d",
+			header.ReplicaCount, header.PartShift)
+	}
+
+	ring := &ringSnapshot{
+		devs:         header.Devs,
+		replicaCount: header.ReplicaCount,
+		partShift:    header.PartShift,
+	}
+	parts := 1 << (32 - header.PartShift)
+	for i := 0; i < header.ReplicaCount; i++ {
+		part2devId := make([]uint16, parts)
+		if err := binary.Read(gz, binary.LittleEndian, part2devId); err != nil {
+			return nil, err
+		}
+		for _, devId := range part2devId {
+			if int(devId) >= len(header.Devs) {
+				return nil, fmt.Errorf("invalid device id %d in replica %d", devId, i)
+			}
+		}
+		ring.replica2part2devId = append(ring.replica2part2devId, part2devId)
+	}
+	return ring, nil
+}
+
+// AllDevices returns the devices of the ring
+func (r *ringSnapshot) AllDevices() []hummingbird.Device {
+	return r.devs
+}
+
+// GetNodesInOrder returns the devices of the partition in replica order, or
+// nil if the partition is out of the ring range
+func (r *ringSnapshot) GetNodesInOrder(partition uint64) []*hummingbird.Device {
+	if partition >= uint64(len(r.replica2part2devId[0])) {
+		return nil
+	}
+	var nodes []*hummingbird.Device
+	for _, part2devId := range r.replica2part2devId {
+		nodes = append(nodes, &r.devs[part2devId[partition]])
+	}
+	return nodes
+}
+
+// GetJobNodes returns the devices of the partition other than localDevice
+// and whether the partition is a handoff on localDevice
+func (r *ringSnapshot) GetJobNodes(partition uint64, localDevice int) ([]*hummingbird.Device, bool) {
+	nodes := r.GetNodesInOrder(partition)
+	if nodes == nil {
+		return nil, false
+	}
+
+	var peers []*hummingbird.Device
+	handoff := true
+	for _, dev := range nodes {
+		if dev.Id == localDevice {
+			handoff = false
+		} else {
+			peers = append(peers, dev)
+		}
+	}
+	return peers, handoff
+}
+
+// GetPartition returns the partition of an account, container or object
+func (r *ringSnapshot) GetPartition(account string, container string, object string) uint64 {
+	hash := md5.New()
+	hash.Write([]byte(r.prefix + "/" + account))
+	if container != "" {
+		hash.Write([]byte("/" + container))
+		if object != "" {
+			hash.Write([]byte("/" + object))
+		}
+	}
+	hash.Write([]byte(r.suffix))
+	digest := hash.Sum(nil)
+	// treat as big endian unsigned int
+	val := uint64(digest[0])<<24 | uint64(digest[1])<<16 | uint64(digest[2])<<8 | uint64(digest[3])
+	return val >> r.partShift
+}
+
+// loadRing returns a snapshot of the ring stored at path. Snapshots are
+// immutable, so one is shared by all resources and scans until the ring file
+// is replaced
+func loadRing(path, prefix, suffix string) (*ringSnapshot, error) {
+	key := strings.Join([]string{path, prefix, suffix}, "\x00")
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	ringsLock.Lock()
+	defer ringsLock.Unlock()
+
+	if ring, ok := rings[key]; ok && ring.mtime.Equal(info.ModTime()) && ring.size == info.Size() {
+		return ring, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ring, err := parseRing(data)
+	if err != nil {
+		return nil, fmt.Errorf("load ring(%s) failed: %v", path, err)
+	}
+	ring.prefix = prefix
+	ring.suffix = suffix
+	ring.mtime = info.ModTime()
+	ring.size = int64(len(data))
+	ring.cksum = fmt.Sprintf("%x", md5.Sum(data))
+
+	rings[key] = ring
+	return ring, nil
+}
@@ -0,0 +1,391 @@
+// +build !integration
+
+package indexer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	pickle "github.com/hydrogen18/stalecucumber"
+	"github.com/openstack/swift/go/hummingbird"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+const testSwiftConf = `[swift-hash]
+swift_hash_path_prefix = pre
+swift_hash_path_suffix = suf
+
+[storage-policy:0]
+name = gold
+
+[storage-policy:1]
+name = silver
+aliases = ag, argent
+default = yes
+
+[storage-policy:2]
+name = ec
+policy_type = erasure_coding
+ec_num_data_fragments = 4
+ec_num_parity_fragments = 2
+`
+
+var testDevs = []hummingbird.Device{
+	{Id: 0, Device: "sdb", Ip: "127.0.0.1", Port: 6000, Region: 1, Zone: 1, Weight: 1},
+	{Id: 1, Device: "sdc", Ip: "10.0.0.2", Port: 6000, Region: 1, Zone: 2, Weight: 1},
+	{Id: 2, Device: "sdd", Ip: "10.0.0.3", Port: 6000, Region: 1, Zone: 3, Weight: 1},
+	{Id: 3, Device: "sde", Ip: "10.0.0.4", Port: 6000, Region: 1, Zone: 4, Weight: 1},
+}
+
+// writeTestRing writes a ring file with 2^(32-partShift) partitions, replica r
+// of partition p is assigned to device (p + r + rot) % len(devs)
+func writeTestRing(t *testing.T, path string, devs []hummingbird.Device, replicas int, partShift uint, rot int) {
+	meta, err := json.Marshal(map[string]interface{}{
+		"devs":          devs,
+		"replica_count": replicas,
+		"part_shift":    partShift,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte("R1NG"))
+	binary.Write(gz, binary.BigEndian, uint16(1))
+	binary.Write(gz, binary.BigEndian, uint32(len(meta)))
+	gz.Write(meta)
+	parts := 1 << (32 - partShift)
+	for r := 0; r < replicas; r++ {
+		part2dev := make([]uint16, parts)
+		for p := range part2dev {
+			part2dev[p] = uint16((p + r + rot) % len(devs))
+		}
+		binary.Write(gz, binary.LittleEndian, part2dev)
+	}
+	gz.Close()
+
+	if err := ioutil.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testSwiftDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "swiftbeat-swift")
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(filepath.Join(dir, "swift.conf"), []byte(testSwiftConf), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// testNode is device sdb laid out under node of the swift dir, which holds
+// the rings of all resources with sdb as device 0 and 4 partitions: sdb is
+// primary of partition 0, 2 and 3 and partition 1 is a handoff
+type testNode struct {
+	swiftDir string
+	devPath  string
+}
+
+func newTestNode(t *testing.T) *testNode {
+	dir := testSwiftDir(t)
+	for _, name := range []string{"account", "container", "object", "object-1", "object-2"} {
+		writeTestRing(t, filepath.Join(dir, name+".ring.gz"), testDevs, 3, 30, 0)
+	}
+
+	node := &testNode{
+		swiftDir: dir,
+		devPath:  filepath.Join(dir, "node", "sdb"),
+	}
+	node.mkdir(t)
+	return node
+}
+
+// mkdir creates a dir under the device
+func (n *testNode) mkdir(t *testing.T, elem ...string) string {
+	path := filepath.Join(append([]string{n.devPath}, elem...)...)
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// objectName returns the name of an object placed in the partition
+func (n *testNode) objectName(t *testing.T, partId uint64) string {
+	ring, err := loadRing(filepath.Join(n.swiftDir, "object.ring.gz"), "pre", "suf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; ; i++ {
+		name := fmt.Sprintf("/a/c/o%d", i)
+		if ring.GetPartition("a", "c", fmt.Sprintf("o%d", i)) == partId {
+			return name
+		}
+	}
+}
+
+// hashDir creates the hash dir of the object name in its partition
+func (n *testNode) hashDir(t *testing.T, resDir string, name string) string {
+	ring, err := loadRing(filepath.Join(n.swiftDir, "object.ring.gz"), "pre", "suf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	account, container, object, err := splitObjectName(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partId := ring.GetPartition(account, container, object)
+	hash := hashPath("pre", "suf", name)
+	return n.mkdir(t, resDir, fmt.Sprint(partId), hash[len(hash)-3:], hash)
+}
+
+// newDisk returns the disk of the device with the indexer settings on top of
+// sdb bound to 127.0.0.1
+func (n *testNode) newDisk(t *testing.T, settings map[string]interface{}, states *input.States,
+	eventChan chan input.Event, done chan struct{}) *Disk {
+
+	merged := map[string]interface{}{"bind_ip": "127.0.0.1"}
+	for k, v := range settings {
+		merged[k] = v
+	}
+	cfg, err := common.NewConfigFrom(merged)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	disk, err := NewDisk("sdb", n.devPath, SwiftConfig{SwiftDir: n.swiftDir}, cfg,
+		eventChan, done, nil, states, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return disk
+}
+
+// scan runs a full scan of the device and returns the published events
+func (n *testNode) scan(t *testing.T, settings map[string]interface{}, states *input.States) []input.Event {
+	eventChan := make(chan input.Event)
+	disk := n.newDisk(t, settings, states, eventChan, make(chan struct{}))
+
+	go func() {
+		disk.BuildIndex()
+		disk.Wait()
+		close(eventChan)
+	}()
+
+	var events []input.Event
+	for event := range eventChan {
+		events = append(events, event)
+	}
+	return events
+}
+
+// eventsOf returns the events of the given type as published
+func eventsOf(events []input.Event, typ string) []common.MapStr {
+	var found []common.MapStr
+	for _, event := range events {
+		if m := event.ToMapStr(); m["type"] == typ {
+			found = append(found, m)
+		}
+	}
+	return found
+}
+
+// writeFile writes a file of size bytes
+func writeFile(t *testing.T, path string, size int) {
+	if err := ioutil.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// writeMetadata pickles the metadata into the xattrs of the file, split in
+// chunks of chunkSize bytes if positive as Swift does for large metadata
+func writeMetadata(t *testing.T, path string, metadata map[string]string, chunkSize int) {
+	var buf bytes.Buffer
+	if _, err := pickle.NewPickler(&buf).Pickle(metadata); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	for i := 0; len(data) > 0; i++ {
+		chunk := data
+		if chunkSize > 0 && len(chunk) > chunkSize {
+			chunk = chunk[:chunkSize]
+		}
+		data = data[len(chunk):]
+
+		key := metadataKey
+		if i > 0 {
+			key = fmt.Sprintf("%s%d", metadataKey, i)
+		}
+		if err := syscall.Setxattr(path, key, chunk, 0); err != nil {
+			t.Skipf("xattrs not supported: %v", err)
+		}
+	}
+}
+
+func TestSwiftConfigPaths(t *testing.T) {
+	tests := []struct {
+		config    SwiftConfig
+		resType   string
+		policy    int64
+		ringPath  string
+		swiftConf string
+	}{
+		{
+			config:    SwiftConfig{SwiftDir: "/etc/swift"},
+			resType:   "object",
+			ringPath:  "/etc/swift/object.ring.gz",
+			swiftConf: "/etc/swift/swift.conf",
+		},
+		{
+			config:    SwiftConfig{SwiftDir: "/etc/swift"},
+			resType:   "object",
+			policy:    2,
+			ringPath:  "/etc/swift/object-2.ring.gz",
+			swiftConf: "/etc/swift/swift.conf",
+		},
+		{
+			config:    SwiftConfig{SwiftDir: "/etc/swift", RingDir: "/srv/rings", SwiftConf: "/srv/swift.conf"},
+			resType:   "account",
+			ringPath:  "/srv/rings/account.ring.gz",
+			swiftConf: "/srv/swift.conf",
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.ringPath, test.config.ringPath(test.resType, test.policy))
+		assert.Equal(t, test.swiftConf, test.config.swiftConf())
+	}
+}
+
+func TestLoadSwiftInfo(t *testing.T) {
+	dir := testSwiftDir(t)
+	defer os.RemoveAll(dir)
+
+	info, err := loadSwiftInfo(SwiftConfig{SwiftDir: dir})
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, "pre", info.hashPathPrefix)
+	assert.Equal(t, "suf", info.hashPathSuffix)
+
+	tests := []struct {
+		index   int
+		name    string
+		typ     string
+		def     bool
+		aliases []string
+	}{
+		{0, "gold", "replication", false, []string{}},
+		{1, "silver", "replication", true, []string{"ag", "argent"}},
+		{2, "ec", "erasure_coding", false, []string{}},
+	}
+	assert.Len(t, info.policies, len(tests))
+	for _, test := range tests {
+		policy, ok := info.policies[test.index]
+		if !assert.True(t, ok, "policy %d", test.index) {
+			continue
+		}
+		assert.Equal(t, test.name, policy.Name)
+		assert.Equal(t, test.typ, policy.Type)
+		assert.Equal(t, test.def, policy.Default)
+		assert.Equal(t, test.aliases, policy.Aliases)
+	}
+	assert.Equal(t, int64(6), ecFragments(info.policies[2].Config))
+}
+
+func TestLoadSwiftInfoDefaultPolicy(t *testing.T) {
+	conf, err := hummingbird.StringConfig("[swift-hash]\nswift_hash_path_suffix = suf\n")
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	policies := loadPolicies(conf)
+	assert.Len(t, policies, 1)
+	assert.True(t, policies[0].Default)
+	assert.Equal(t, "Policy-0", policies[0].Name)
+}
+
+func TestLoadSwiftInfoMissingSuffix(t *testing.T) {
+	dir := testSwiftDir(t)
+	defer os.RemoveAll(dir)
+
+	conf := filepath.Join(dir, "other.conf")
+	ioutil.WriteFile(conf, []byte("[swift-hash]\nswift_hash_path_prefix = pre\n"), 0644)
+
+	_, err := loadSwiftInfo(SwiftConfig{SwiftDir: dir, SwiftConf: conf})
+	assert.Error(t, err)
+
+	_, err = loadSwiftInfo(SwiftConfig{SwiftDir: filepath.Join(dir, "missing")})
+	assert.Error(t, err)
+}
+
+func TestLoadRing(t *testing.T) {
+	dir := testSwiftDir(t)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "object.ring.gz")
+	writeTestRing(t, path, testDevs, 3, 24, 0)
+
+	ring, err := loadRing(path, "pre", "suf")
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Len(t, ring.AllDevices(), len(testDevs))
+
+	tests := []struct {
+		partId  uint64
+		devices []string
+	}{
+		{0, []string{"sdb", "sdc", "sdd"}},
+		{1, []string{"sdc", "sdd", "sde"}},
+		{3, []string{"sde", "sdb", "sdc"}},
+		{255, []string{"sde", "sdb", "sdc"}},
+	}
+	for _, test := range tests {
+		var devices []string
+		for _, dev := range ring.GetNodesInOrder(test.partId) {
+			devices = append(devices, dev.Device)
+		}
+		assert.Equal(t, test.devices, devices, "partition %d", test.partId)
+	}
+
+	// snapshots are shared per file and hash path settings
+	again, err := loadRing(path, "pre", "suf")
+	assert.NoError(t, err)
+	assert.True(t, ring == again)
+	other, err := loadRing(path, "pre", "other")
+	assert.NoError(t, err)
+	assert.False(t, ring == other)
+	assert.NotEqual(t, ring.GetPartition("a", "c", "o"), other.GetPartition("a", "c", "o"))
+
+	// a rewritten ring file is loaded into a new snapshot, the previous one
+	// is left as it was
+	writeTestRing(t, path, testDevs, 3, 24, 1)
+	mtime := time.Now().Add(time.Minute)
+	os.Chtimes(path, mtime, mtime)
+	rebalanced, err := loadRing(path, "pre", "suf")
+	assert.NoError(t, err)
+	assert.False(t, ring == rebalanced)
+	assert.NotEqual(t, ring.cksum, rebalanced.cksum)
+	assert.Equal(t, "sdb", ring.GetNodesInOrder(0)[0].Device)
+	assert.Equal(t, "sdc", rebalanced.GetNodesInOrder(0)[0].Device)
+
+	_, err = loadRing(filepath.Join(dir, "container.ring.gz"), "pre", "suf")
+	assert.Error(t, err)
+}
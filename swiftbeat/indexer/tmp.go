@@ -0,0 +1,57 @@
+package indexer
+
+import (
+	"path/filepath"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+const (
+	// stale files listed in one tmp event at most
+	maxStaleFiles = 100
+)
+
+// indexTmp reports files written to the tmp dir, which are renamed into
+// place once a write completes. Files older than tmp_stale_age are leftovers
+// of failed writes
+func (d *Disk) indexTmp() {
+	logp.Debug("indexer", "Start indexing tmp dir: %s", d.tmp)
+
+	files, err := readDir(d.limiter, d.tmp)
+	if err != nil {
+		logp.Err("list dir(%s) failed: %v", d.tmp, err)
+		return
+	}
+
+	tmp := swift.TmpDir{
+		Device: d.Name,
+		Path:   d.tmp,
+	}
+
+	now := time.Now()
+	for _, file := range files {
+		if !file.Mode().IsRegular() {
+			continue
+		}
+
+		tmp.NumFiles += 1
+		tmp.BytesTotal += file.Size()
+		if tmp.OldestMtime.IsZero() || file.ModTime().Before(tmp.OldestMtime) {
+			tmp.OldestMtime = file.ModTime()
+		}
+
+		if now.Sub(file.ModTime()) > d.config.TmpStaleAge {
+			tmp.NumStale += 1
+			tmp.StaleBytes += file.Size()
+			if len(tmp.StaleFiles) < maxStaleFiles {
+				tmp.StaleFiles = append(tmp.StaleFiles, filepath.Join(d.tmp, file.Name()))
+			}
+		}
+	}
+	tmp.ScannedAt = now
+
+	d.publish(input.NewTmpDirEvent(tmp))
+}
@@ -0,0 +1,41 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTmp(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	dir := node.mkdir(t, "tmp")
+	writeFile(t, filepath.Join(dir, "fresh"), 10)
+	stale := filepath.Join(dir, "stale")
+	writeFile(t, stale, 100)
+	mtime := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(stale, mtime, mtime)
+	node.mkdir(t, "tmp", "subdir")
+
+	events := node.scan(t, map[string]interface{}{
+		"enable_tmp_index": true,
+		"tmp_stale_age":    "1h",
+	}, nil)
+
+	tmps := eventsOf(events, "tmp")
+	if assert.Len(t, tmps, 1) {
+		tmp := tmps[0]
+		assert.Equal(t, int64(2), tmp["num_files"])
+		assert.Equal(t, int64(110), tmp["bytes_total"])
+		assert.Equal(t, int64(1), tmp["num_stale"])
+		assert.Equal(t, int64(100), tmp["stale_bytes"])
+		assert.Equal(t, []string{stale}, tmp["stale_files"])
+		assert.InDelta(t, 2, tmp["oldest_age_hours"], 0.1)
+	}
+}
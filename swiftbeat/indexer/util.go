@@ -1,15 +1,84 @@
 package indexer
 
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// Semaphore limits number of goroutines doing IO simultaneously
+// nil Semaphore means no limit
 type Semaphore chan bool
 
+// NewSemaphore returns a Semaphore of size n, or nil if n is not positive
 func NewSemaphore(n int) Semaphore {
+	if n <= 0 {
+		return nil
+	}
 	return make(Semaphore, n)
 }
 
 func (s Semaphore) acquire() {
+	if s == nil {
+		return
+	}
 	s <- true
 }
 
 func (s Semaphore) release() {
+	if s == nil {
+		return
+	}
 	<-s
 }
+
+// RateLimiter spaces out IO operations evenly to stay under a fixed rate
+// nil RateLimiter means no limit
+type RateLimiter struct {
+	interval time.Duration
+	next     time.Time
+	lock     sync.Mutex
+	done     chan struct{}
+}
+
+// NewRateLimiter returns a RateLimiter allowing rate operations per second,
+// or nil if rate is not positive. Waiting is interrupted once done is closed
+func NewRateLimiter(rate int, done chan struct{}) *RateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &RateLimiter{
+		interval: time.Second / time.Duration(rate),
+		done:     done,
+	}
+}
+
+// wait blocks until next operation is allowed or done is closed
+func (l *RateLimiter) wait() {
+	if l == nil {
+		return
+	}
+
+	l.lock.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	l.lock.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-l.done:
+		case <-time.After(delay):
+		}
+	}
+}
+
+// helper function to list dir with IO rate limit applied
+func readDir(limiter *RateLimiter, path string) ([]os.FileInfo, error) {
+	limiter.wait()
+	return ioutil.ReadDir(path)
+}
@@ -0,0 +1,95 @@
+// +build !integration
+
+package indexer
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemaphore(t *testing.T) {
+	assert.Nil(t, NewSemaphore(0))
+
+	// nil semaphore never blocks
+	var unlimited Semaphore
+	unlimited.acquire()
+	unlimited.acquire()
+	unlimited.release()
+
+	sem := NewSemaphore(2)
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	running, maxRunning := 0, 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem.acquire()
+			defer sem.release()
+
+			lock.Lock()
+			running += 1
+			if running > maxRunning {
+				maxRunning = running
+			}
+			lock.Unlock()
+
+			time.Sleep(5 * time.Millisecond)
+
+			lock.Lock()
+			running -= 1
+			lock.Unlock()
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, maxRunning)
+}
+
+func TestRateLimiter(t *testing.T) {
+	assert.Nil(t, NewRateLimiter(0, nil))
+
+	// nil limiter never waits
+	var unlimited *RateLimiter
+	unlimited.wait()
+
+	// 11 operations at 100/s are spaced out over 100ms
+	limiter := NewRateLimiter(100, make(chan struct{}))
+	start := time.Now()
+	for i := 0; i < 11; i++ {
+		limiter.wait()
+	}
+	assert.True(t, time.Since(start) >= 100*time.Millisecond)
+
+	// waiting is interrupted once done is closed
+	done := make(chan struct{})
+	limiter = NewRateLimiter(1, done)
+	limiter.wait()
+	close(done)
+	start = time.Now()
+	limiter.wait()
+	assert.True(t, time.Since(start) < 500*time.Millisecond)
+}
+
+func TestScanThrottled(t *testing.T) {
+	node := newTestNode(t)
+	defer os.RemoveAll(node.swiftDir)
+
+	for _, part := range []string{"0", "1", "2", "3"} {
+		node.mkdir(t, "objects", part)
+	}
+
+	// all partitions are indexed by the bounded worker pool
+	events := node.scan(t, map[string]interface{}{
+		"partition_workers": 2,
+		"max_iops":          1000,
+	}, nil)
+	parts := map[int64]bool{}
+	for _, part := range eventsOf(events, "obj_partition") {
+		parts[part["partition"].(int64)] = true
+	}
+	assert.Equal(t, map[int64]bool{0: true, 1: true, 2: true, 3: true}, parts)
+}
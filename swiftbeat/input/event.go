@@ -19,28 +19,58 @@ type Event interface {
 	GetTTL() time.Duration
 }
 
+// untrackedEvent implements the Event methods shared by events which are
+// published once and not tracked in partition states
+type untrackedEvent struct{}
+
+func (ev untrackedEvent) Bytes() int {
+	return 1
+}
+
+// ToPartition returns nil since the event is not tracked in partition states
+func (ev untrackedEvent) ToPartition() *swift.Partition {
+	return nil
+}
+
+func (ev untrackedEvent) GetTTL() time.Duration {
+	return -1 * time.Second
+}
+
+func (ev untrackedEvent) SetTTL(ttl time.Duration) {
+	return
+}
+
 var knownObjectMetaKey = []string{
 	"name",
 	"Content-Type",
 	"Content-Length",
-	"X-Object-Meta-Mtime",
 	"X-Timestamp",
 	"ETag",
+	"X-Delete-At",
+}
+
+// user metadata and sysmeta keys are copied to event as is
+var knownObjectMetaPrefix = []string{
+	"X-Object-Meta-",
+	"X-Object-Sysmeta-",
 }
 
 var str2intObjectFields = []string{
 	"content-length",
 	"partition",
+	"x-delete-at",
 }
 
 type ObjectEvent struct {
 	common.EventMetadata
 	Object swift.Object
+	ttl    time.Duration
 }
 
 func NewObjectEvent(object swift.Object) *ObjectEvent {
 	return &ObjectEvent{
 		Object: object,
+		ttl:    -1 * time.Second,
 	}
 }
 
@@ -56,6 +86,24 @@ func (ev *ObjectEvent) ToMapStr() common.MapStr {
 		"path":         ev.Object.Path,
 		"peer_devices": ev.Object.PeerDevices,
 		"peer_ips":     ev.Object.PeerIps,
+		"policy_index": ev.Object.PolicyIndex,
+		"policy_name":  ev.Object.PolicyName,
+	}
+
+	if _, ok := ev.Object.Metadata["X-Delete-At"]; ok {
+		event["expired"] = ev.Object.Expired
+	}
+
+	if ev.Object.FragIndex >= 0 {
+		event["frag_index"] = ev.Object.FragIndex
+		event["durable"] = ev.Object.Durable
+		event["frag_misplaced"] = ev.Object.FragMisplaced
+	}
+
+	if part := ev.Object.Part; part != nil {
+		event["indexed_at"] = common.Time(part.IndexedAt)
+		event["replica_id"] = part.ReplicaId
+		event["ring_mtime"] = common.Time(part.RingMtime)
 	}
 
 	// copy object metadata key / values to event
@@ -65,6 +113,15 @@ func (ev *ObjectEvent) ToMapStr() common.MapStr {
 		}
 	}
 
+	for k, v := range ev.Object.Metadata {
+		for _, prefix := range knownObjectMetaPrefix {
+			if strings.HasPrefix(k, prefix) {
+				event[strings.ToLower(k)] = v
+				break
+			}
+		}
+	}
+
 	for _, k := range str2intObjectFields {
 		if v, ok := event[k]; ok {
 			if vInt, err := strconv.ParseInt(v.(string), 10, 64); err == nil {
@@ -84,16 +141,25 @@ func (ev *ObjectEvent) ResourceType() string {
 	return "object"
 }
 
+// ToPartition returns the hash dir of the object within its partition, so
+// objects are tracked at hash dir granularity
 func (ev *ObjectEvent) ToPartition() *swift.Partition {
-	return nil
+	if ev.Object.Part == nil {
+		return nil
+	}
+
+	part := *ev.Object.Part
+	part.Hash = ev.Object.Hash
+	part.Mtime = ev.Object.HashMtime
+	return &part
 }
 
 func (ev *ObjectEvent) GetTTL() time.Duration {
-	return -1 * time.Second
+	return ev.ttl
 }
 
 func (ev *ObjectEvent) SetTTL(ttl time.Duration) {
-	return
+	ev.ttl = ttl
 }
 
 type ObjectPartitionEvent struct {
@@ -128,6 +194,27 @@ func (ev *ObjectPartitionEvent) ToMapStr() common.MapStr {
 		"peer_devices":   ev.ObjPart.PeerDevices,
 		"peer_ips":       ev.ObjPart.PeerIps,
 		"ring_cksum":     ev.ObjPart.RingCKSum,
+		"policy_index":   ev.ObjPart.PolicyIndex,
+		"policy_name":    ev.ObjPart.PolicyName,
+		// suffix hash state from hashes.pkl
+		"num_suffixes":         ev.ObjPart.NumSuffixes,
+		"num_invalid_suffixes": ev.ObjPart.NumInvalidSuffixes,
+		"num_missing_suffixes": ev.ObjPart.NumMissingSuffixes,
+	}
+
+	if !ev.ObjPart.HashesMtime.IsZero() {
+		event["hashes_mtime"] = common.Time(ev.ObjPart.HashesMtime)
+	}
+
+	if ev.ObjPart.NumExpired >= 0 {
+		event["num_expired"] = ev.ObjPart.NumExpired
+		event["expired_bytes"] = ev.ObjPart.ExpiredBytes
+	}
+
+	// fragment archive state is reported for EC policies only
+	if ev.ObjPart.NumNonDurableFrags >= 0 {
+		event["num_non_durable_frags"] = ev.ObjPart.NumNonDurableFrags
+		event["num_misplaced_frags"] = ev.ObjPart.NumMisplacedFrags
 	}
 
 	return event
@@ -169,28 +256,46 @@ func NewContainerEvent(container swift.Container) *ContainerEvent {
 func (ev *ContainerEvent) ToMapStr() common.MapStr {
 
 	event := common.MapStr{
-		"@timestamp":    common.Time(ev.Container.IndexedAt),
-		"type":          "db",
-		"mtime":         common.Time(ev.Container.Mtime),
-		"path":          ev.Container.Path,
-		"db_size_kb":    ev.Container.SizeKB,
-		"account":       ev.Container.Account,
-		"container":     ev.Container.Container,
-		"status":        ev.Container.Status,
-		"object_count":  ev.Container.ObjectCount,
-		"bytes_used_mb": ev.Container.BytesUsedMB,
-		"policy_index":  ev.Container.PolicyIndex,
-		"indexed_at":    common.Time(ev.Container.IndexedAt),
-		"resource_type": ev.Container.ResourceType,
-		"partition":     ev.Container.PartId,
-		"device":        ev.Container.Device,
-		"ip":            ev.Container.Ip,
-		"ring_mtime":    common.Time(ev.Container.RingMtime),
-		"handoff":       ev.Container.Handoff,
-		"replica_id":    ev.Container.ReplicaId,
-		"peer_devices":  ev.Container.PeerDevices,
-		"peer_ips":      ev.Container.PeerIps,
-		"ring_cksum":    ev.Container.RingCKSum,
+		"@timestamp":      common.Time(ev.Container.IndexedAt),
+		"type":            "db",
+		"mtime":           common.Time(ev.Container.Mtime),
+		"path":            ev.Container.Path,
+		"db_size_kb":      ev.Container.SizeKB,
+		"account":         ev.Container.Account,
+		"container":       ev.Container.Container,
+		"status":          ev.Container.Status,
+		"object_count":    ev.Container.ObjectCount,
+		"bytes_used_mb":   ev.Container.BytesUsedMB,
+		"policy_index":    ev.Container.PolicyIndex,
+		"indexed_at":      common.Time(ev.Container.IndexedAt),
+		"resource_type":   ev.Container.ResourceType,
+		"partition":       ev.Container.PartId,
+		"device":          ev.Container.Device,
+		"ip":              ev.Container.Ip,
+		"ring_mtime":      common.Time(ev.Container.RingMtime),
+		"handoff":         ev.Container.Handoff,
+		"replica_id":      ev.Container.ReplicaId,
+		"peer_devices":    ev.Container.PeerDevices,
+		"peer_ips":        ev.Container.PeerIps,
+		"ring_cksum":      ev.Container.RingCKSum,
+		"pending_size_kb": ev.Container.PendingSize / 1024,
+	}
+
+	if ev.Container.ObjectRows >= 0 {
+		event["object_rows"] = ev.Container.ObjectRows
+		event["deleted_rows"] = ev.Container.DeletedRows
+		if !ev.Container.OldestObject.IsZero() {
+			event["oldest_object"] = common.Time(ev.Container.OldestObject)
+			event["newest_object"] = common.Time(ev.Container.NewestObject)
+		}
+	}
+
+	if ev.Container.ShardRanges != nil {
+		shardRanges := common.MapStr{}
+		for state, count := range ev.Container.ShardRanges {
+			shardRanges[state] = count
+		}
+		event["shard_ranges"] = shardRanges
 	}
 
 	return event
@@ -277,3 +382,596 @@ func (ev *AccountEvent) GetTTL() time.Duration {
 func (ev *AccountEvent) SetTTL(ttl time.Duration) {
 	ev.ttl = ttl
 }
+
+type RingChangedEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Change swift.RingChange
+}
+
+func NewRingChangedEvent(change swift.RingChange) *RingChangedEvent {
+	return &RingChangedEvent{
+		Change: change,
+	}
+}
+
+func (ev *RingChangedEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":           common.Time(ev.Change.DetectedAt),
+		"type":                 "ring_changed",
+		"resource_type":        ev.Change.ResourceType,
+		"policy_index":         ev.Change.PolicyIndex,
+		"policy_name":          ev.Change.PolicyName,
+		"device":               ev.Change.Device,
+		"ip":                   ev.Change.Ip,
+		"ring_mtime":           common.Time(ev.Change.RingMtime),
+		"ring_cksum":           ev.Change.RingCKSum,
+		"prev_ring_mtime":      common.Time(ev.Change.PrevRingMtime),
+		"prev_ring_cksum":      ev.Change.PrevRingCKSum,
+		"parts_moved_in":       ev.Change.PartsMovedIn,
+		"parts_moved_out":      ev.Change.PartsMovedOut,
+		"parts_reassigned":     ev.Change.PartsReassigned,
+		"num_parts_moved_in":   len(ev.Change.PartsMovedIn),
+		"num_parts_moved_out":  len(ev.Change.PartsMovedOut),
+		"num_parts_reassigned": len(ev.Change.PartsReassigned),
+	}
+
+	return event
+}
+
+func (ev *RingChangedEvent) ResourceType() string {
+	return ev.Change.ResourceType
+}
+
+type HandoffSummaryEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Summary swift.HandoffSummary
+}
+
+func NewHandoffSummaryEvent(summary swift.HandoffSummary) *HandoffSummaryEvent {
+	return &HandoffSummaryEvent{
+		Summary: summary,
+	}
+}
+
+func (ev *HandoffSummaryEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":           common.Time(ev.Summary.ScannedAt),
+		"type":                 "handoff_summary",
+		"resource_type":        ev.Summary.ResourceType,
+		"policy_index":         ev.Summary.PolicyIndex,
+		"policy_name":          ev.Summary.PolicyName,
+		"device":               ev.Summary.Device,
+		"ip":                   ev.Summary.Ip,
+		"ring_mtime":           common.Time(ev.Summary.RingMtime),
+		"num_parts":            ev.Summary.NumParts,
+		"num_handoff_parts":    ev.Summary.NumHandoffParts,
+		"num_datafiles":        ev.Summary.NumDatafiles,
+		"bytes_total_mb":       ev.Summary.BytesTotalMB,
+		"num_drained_parts":    ev.Summary.NumDrainedParts,
+		"num_new_parts":        ev.Summary.NumNewParts,
+		"drain_parts_per_hour": ev.Summary.DrainPartsPerHour,
+		"drain_mb_per_hour":    ev.Summary.DrainMBPerHour,
+		"drain_eta_hours":      ev.Summary.DrainETAHours,
+	}
+
+	if !ev.Summary.PrevScannedAt.IsZero() {
+		event["prev_scanned_at"] = common.Time(ev.Summary.PrevScannedAt)
+	}
+
+	return event
+}
+
+func (ev *HandoffSummaryEvent) ResourceType() string {
+	return ev.Summary.ResourceType
+}
+
+type ObjectAuditEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Audit swift.ObjectAudit
+}
+
+func NewObjectAuditEvent(audit swift.ObjectAudit) *ObjectAuditEvent {
+	return &ObjectAuditEvent{
+		Audit: audit,
+	}
+}
+
+func (ev *ObjectAuditEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":    common.Time(ev.Audit.AuditedAt),
+		"type":          "object_audit",
+		"finding":       ev.Audit.Finding,
+		"detail":        ev.Audit.Detail,
+		"path":          ev.Audit.Path,
+		"hash":          ev.Audit.Hash,
+		"suffix":        ev.Audit.Suffix,
+		"resource_type": ev.Audit.ResourceType,
+		"partition":     ev.Audit.PartId,
+		"device":        ev.Audit.Device,
+		"ip":            ev.Audit.Ip,
+		"handoff":       ev.Audit.Handoff,
+		"policy_index":  ev.Audit.PolicyIndex,
+		"policy_name":   ev.Audit.PolicyName,
+	}
+
+	return event
+}
+
+func (ev *ObjectAuditEvent) ResourceType() string {
+	return ev.Audit.ResourceType
+}
+
+type MisplacedObjectEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Misplaced swift.MisplacedObject
+}
+
+func NewMisplacedObjectEvent(misplaced swift.MisplacedObject) *MisplacedObjectEvent {
+	return &MisplacedObjectEvent{
+		Misplaced: misplaced,
+	}
+}
+
+func (ev *MisplacedObjectEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":         common.Time(ev.Misplaced.CheckedAt),
+		"type":               "misplaced_object",
+		"name":               ev.Misplaced.Name,
+		"hash":               ev.Misplaced.Hash,
+		"path":               ev.Misplaced.Path,
+		"expected_partition": ev.Misplaced.ExpectedPartId,
+		"expected_hash":      ev.Misplaced.ExpectedHash,
+		"primary_devices":    ev.Misplaced.PrimaryDevices,
+		"primary_ips":        ev.Misplaced.PrimaryIps,
+		"resource_type":      ev.Misplaced.ResourceType,
+		"partition":          ev.Misplaced.PartId,
+		"device":             ev.Misplaced.Device,
+		"ip":                 ev.Misplaced.Ip,
+		"ring_mtime":         common.Time(ev.Misplaced.RingMtime),
+		"ring_cksum":         ev.Misplaced.RingCKSum,
+		"policy_index":       ev.Misplaced.PolicyIndex,
+		"policy_name":        ev.Misplaced.PolicyName,
+	}
+
+	return event
+}
+
+func (ev *MisplacedObjectEvent) ResourceType() string {
+	return ev.Misplaced.ResourceType
+}
+
+type ReplicaHealthEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Health swift.ReplicaHealth
+}
+
+func NewReplicaHealthEvent(health swift.ReplicaHealth) *ReplicaHealthEvent {
+	return &ReplicaHealthEvent{
+		Health: health,
+	}
+}
+
+func (ev *ReplicaHealthEvent) ToMapStr() common.MapStr {
+
+	var peers []common.MapStr
+	for _, peer := range ev.Health.Peers {
+		p := common.MapStr{
+			"device":                  peer.Device,
+			"ip":                      peer.Ip,
+			"port":                    peer.Port,
+			"status":                  peer.Status,
+			"num_suffixes":            peer.NumSuffixes,
+			"num_mismatched_suffixes": peer.NumMismatchedSuffixes,
+			"num_missing_suffixes":    peer.NumMissingSuffixes,
+		}
+		if peer.Detail != "" {
+			p["detail"] = peer.Detail
+		}
+		// last replication pass is known only if recon of the peer answered
+		if !peer.ReplicationLast.IsZero() {
+			p["replication_last"] = common.Time(peer.ReplicationLast)
+		}
+		peers = append(peers, p)
+	}
+
+	event := common.MapStr{
+		"@timestamp":    common.Time(ev.Health.CheckedAt),
+		"type":          "replica_health",
+		"source":        ev.Health.Source,
+		"num_suffixes":  ev.Health.NumSuffixes,
+		"num_peers":     len(ev.Health.Peers),
+		"num_healthy":   ev.Health.NumHealthy,
+		"healthy":       ev.Health.NumHealthy == int64(len(ev.Health.Peers)),
+		"peers":         peers,
+		"resource_type": ev.Health.ResourceType,
+		"partition":     ev.Health.PartId,
+		"device":        ev.Health.Device,
+		"ip":            ev.Health.Ip,
+		"replica_id":    ev.Health.ReplicaId,
+		"peer_devices":  ev.Health.PeerDevices,
+		"peer_ips":      ev.Health.PeerIps,
+		"ring_mtime":    common.Time(ev.Health.RingMtime),
+		"ring_cksum":    ev.Health.RingCKSum,
+		"policy_index":  ev.Health.PolicyIndex,
+		"policy_name":   ev.Health.PolicyName,
+	}
+
+	return event
+}
+
+func (ev *ReplicaHealthEvent) ResourceType() string {
+	return ev.Health.ResourceType
+}
+
+type QuarantineEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Quarantine swift.Quarantine
+}
+
+func NewQuarantineEvent(quarantine swift.Quarantine) *QuarantineEvent {
+	return &QuarantineEvent{
+		Quarantine: quarantine,
+	}
+}
+
+func (ev *QuarantineEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":      common.Time(ev.Quarantine.ScannedAt),
+		"type":            "quarantined",
+		"device":          ev.Quarantine.Device,
+		"quarantine_type": ev.Quarantine.QuarantineType,
+		"resource_type":   ev.Quarantine.ResourceType,
+		"policy_index":    ev.Quarantine.PolicyIndex,
+		"num_items":       ev.Quarantine.NumItems,
+	}
+
+	if ev.Quarantine.NumItems > 0 {
+		event["oldest_mtime"] = common.Time(ev.Quarantine.OldestMtime)
+		event["newest_mtime"] = common.Time(ev.Quarantine.NewestMtime)
+		event["oldest_age_hours"] = ev.Quarantine.ScannedAt.Sub(ev.Quarantine.OldestMtime).Hours()
+		event["newest_age_hours"] = ev.Quarantine.ScannedAt.Sub(ev.Quarantine.NewestMtime).Hours()
+	}
+
+	return event
+}
+
+func (ev *QuarantineEvent) ResourceType() string {
+	return "quarantined"
+}
+
+type AsyncPendingEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Pending swift.AsyncPending
+}
+
+func NewAsyncPendingEvent(pending swift.AsyncPending) *AsyncPendingEvent {
+	return &AsyncPendingEvent{
+		Pending: pending,
+	}
+}
+
+func (ev *AsyncPendingEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":       common.Time(ev.Pending.ScannedAt),
+		"type":             "async_pending",
+		"device":           ev.Pending.Device,
+		"policy_index":     ev.Pending.PolicyIndex,
+		"suffix":           ev.Pending.Suffix,
+		"path":             ev.Pending.Path,
+		"num_updates":      ev.Pending.NumUpdates,
+		"oldest_mtime":     common.Time(ev.Pending.OldestMtime),
+		"oldest_age_hours": ev.Pending.ScannedAt.Sub(ev.Pending.OldestMtime).Hours(),
+		"sample_path":      ev.Pending.SamplePath,
+	}
+
+	// sample is left out if the update could not be unpickled
+	if ev.Pending.SampleContainer != "" {
+		event["sample_op"] = ev.Pending.SampleOp
+		event["sample_account"] = ev.Pending.SampleAccount
+		event["sample_container"] = ev.Pending.SampleContainer
+		event["sample_object"] = ev.Pending.SampleObject
+	}
+
+	return event
+}
+
+func (ev *AsyncPendingEvent) ResourceType() string {
+	return "async_pending"
+}
+
+type TmpDirEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Tmp swift.TmpDir
+}
+
+func NewTmpDirEvent(tmp swift.TmpDir) *TmpDirEvent {
+	return &TmpDirEvent{
+		Tmp: tmp,
+	}
+}
+
+func (ev *TmpDirEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":  common.Time(ev.Tmp.ScannedAt),
+		"type":        "tmp",
+		"device":      ev.Tmp.Device,
+		"path":        ev.Tmp.Path,
+		"num_files":   ev.Tmp.NumFiles,
+		"bytes_total": ev.Tmp.BytesTotal,
+		"num_stale":   ev.Tmp.NumStale,
+		"stale_bytes": ev.Tmp.StaleBytes,
+		"stale_files": ev.Tmp.StaleFiles,
+	}
+
+	if ev.Tmp.NumFiles > 0 {
+		event["oldest_mtime"] = common.Time(ev.Tmp.OldestMtime)
+		event["oldest_age_hours"] = ev.Tmp.ScannedAt.Sub(ev.Tmp.OldestMtime).Hours()
+	}
+
+	return event
+}
+
+func (ev *TmpDirEvent) ResourceType() string {
+	return "tmp"
+}
+
+type DBRollupEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Rollup swift.DBRollup
+}
+
+func NewDBRollupEvent(rollup swift.DBRollup) *DBRollupEvent {
+	return &DBRollupEvent{
+		Rollup: rollup,
+	}
+}
+
+func (ev *DBRollupEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":       common.Time(ev.Rollup.ScannedAt),
+		"type":             "db_rollup",
+		"resource_type":    ev.Rollup.ResourceType,
+		"account":          ev.Rollup.Account,
+		"num_replicas":     ev.Rollup.NumReplicas,
+		"num_handoffs":     ev.Rollup.NumHandoffs,
+		"num_deleted":      ev.Rollup.NumDeleted,
+		"devices":          ev.Rollup.Devices,
+		"partitions":       ev.Rollup.Partitions,
+		"statuses":         ev.Rollup.Statuses,
+		"object_count_min": ev.Rollup.ObjectCountMin,
+		"object_count_max": ev.Rollup.ObjectCountMax,
+		"bytes_used_min":   ev.Rollup.BytesUsedMin,
+		"bytes_used_max":   ev.Rollup.BytesUsedMax,
+		"consistent":       ev.Rollup.Consistent,
+	}
+
+	// object count and bytes used agreed by all replicas
+	if ev.Rollup.Consistent {
+		event["object_count"] = ev.Rollup.ObjectCountMax
+		event["bytes_used_mb"] = ev.Rollup.BytesUsedMax / 1024 / 1024
+	}
+
+	if ev.Rollup.ResourceType == "account" {
+		event["container_count_min"] = ev.Rollup.ContainerCountMin
+		event["container_count_max"] = ev.Rollup.ContainerCountMax
+		if ev.Rollup.Consistent {
+			event["container_count"] = ev.Rollup.ContainerCountMax
+		}
+	} else {
+		event["container"] = ev.Rollup.Container
+	}
+
+	return event
+}
+
+func (ev *DBRollupEvent) ResourceType() string {
+	return ev.Rollup.ResourceType
+}
+
+type ScanSummaryEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Summary swift.ScanSummary
+}
+
+func NewScanSummaryEvent(summary swift.ScanSummary) *ScanSummaryEvent {
+	return &ScanSummaryEvent{
+		Summary: summary,
+	}
+}
+
+func (ev *ScanSummaryEvent) ToMapStr() common.MapStr {
+
+	errors := common.MapStr{}
+	for kind, count := range ev.Summary.Errors {
+		errors[kind] = count
+	}
+
+	event := common.MapStr{
+		"@timestamp":      common.Time(ev.Summary.ReportedAt),
+		"type":            "scan_summary",
+		"resource_type":   ev.Summary.ResourceType,
+		"policy_index":    ev.Summary.PolicyIndex,
+		"policy_name":     ev.Summary.PolicyName,
+		"device":          ev.Summary.Device,
+		"ip":              ev.Summary.Ip,
+		"started_at":      common.Time(ev.Summary.StartedAt),
+		"finished":        ev.Summary.Finished,
+		"duration_ms":     ev.Summary.DurationMs,
+		"num_parts":       ev.Summary.NumParts,
+		"parts_scanned":   ev.Summary.PartsScanned,
+		"parts_skipped":   ev.Summary.PartsSkipped,
+		"suffixes_walked": ev.Summary.SuffixesWalked,
+		"hashes_walked":   ev.Summary.HashesWalked,
+		"files_indexed":   ev.Summary.FilesIndexed,
+		"errors":          errors,
+	}
+
+	if ev.Summary.Finished {
+		event["finished_at"] = common.Time(ev.Summary.FinishedAt)
+	}
+
+	return event
+}
+
+func (ev *ScanSummaryEvent) ResourceType() string {
+	return ev.Summary.ResourceType
+}
+
+type DeviceEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Change swift.DeviceChange
+}
+
+func NewDeviceEvent(change swift.DeviceChange) *DeviceEvent {
+	return &DeviceEvent{
+		Change: change,
+	}
+}
+
+func (ev *DeviceEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp": common.Time(ev.Change.DetectedAt),
+		"type":       "device_" + ev.Change.Change,
+		"device":     ev.Change.Device,
+		"path":       ev.Change.Path,
+	}
+
+	return event
+}
+
+func (ev *DeviceEvent) ResourceType() string {
+	return ""
+}
+
+type DeviceNotInRingEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	NotInRing swift.DeviceNotInRing
+}
+
+func NewDeviceNotInRingEvent(notInRing swift.DeviceNotInRing) *DeviceNotInRingEvent {
+	return &DeviceNotInRingEvent{
+		NotInRing: notInRing,
+	}
+}
+
+func (ev *DeviceNotInRingEvent) ToMapStr() common.MapStr {
+
+	event := common.MapStr{
+		"@timestamp":    common.Time(ev.NotInRing.DetectedAt),
+		"type":          "device_not_in_ring",
+		"resource_type": ev.NotInRing.ResourceType,
+		"policy_index":  ev.NotInRing.PolicyIndex,
+		"policy_name":   ev.NotInRing.PolicyName,
+		"device":        ev.NotInRing.Device,
+		"bind_ips":      ev.NotInRing.BindIps,
+		"bind_port":     ev.NotInRing.BindPort,
+		"ring_mtime":    common.Time(ev.NotInRing.RingMtime),
+		"ring_cksum":    ev.NotInRing.RingCKSum,
+		"skipped":       ev.NotInRing.Skipped,
+	}
+
+	return event
+}
+
+func (ev *DeviceNotInRingEvent) ResourceType() string {
+	return ev.NotInRing.ResourceType
+}
+
+// StateRemoval identifies partition states to be removed from the registry
+// all states of the device are removed if ResourceType is empty
+type StateRemoval struct {
+	Device       string
+	ResourceType string
+	Key          string
+}
+
+// StateRemovalEvent is a state update only event, it is not published
+type StateRemovalEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Removal StateRemoval
+}
+
+func NewStateRemovalEvent(removal StateRemoval) *StateRemovalEvent {
+	return &StateRemovalEvent{
+		Removal: removal,
+	}
+}
+
+func (ev *StateRemovalEvent) ToMapStr() common.MapStr {
+	return common.MapStr{
+		"device":        ev.Removal.Device,
+		"resource_type": ev.Removal.ResourceType,
+		"key":           ev.Removal.Key,
+	}
+}
+
+// Bytes returns 0 since the event carries state update only
+func (ev *StateRemovalEvent) Bytes() int {
+	return 0
+}
+
+func (ev *StateRemovalEvent) ResourceType() string {
+	return ev.Removal.ResourceType
+}
+
+// PartitionScan records an object partition walked, or skipped as unchanged,
+// by an incremental scan
+type PartitionScan struct {
+	Partition *swift.Partition
+	Unchanged bool
+	// hash dirs found under the walked partition
+	Hashes []string
+}
+
+// PartitionScanEvent is a state update only event, it is not published
+type PartitionScanEvent struct {
+	untrackedEvent
+	common.EventMetadata
+	Scan PartitionScan
+}
+
+func NewPartitionScanEvent(scan PartitionScan) *PartitionScanEvent {
+	return &PartitionScanEvent{
+		Scan: scan,
+	}
+}
+
+func (ev *PartitionScanEvent) ToMapStr() common.MapStr {
+	return common.MapStr{
+		"device":        ev.Scan.Partition.Device,
+		"resource_type": ev.Scan.Partition.ResourceType,
+		"partition":     ev.Scan.Partition.PartId,
+		"unchanged":     ev.Scan.Unchanged,
+	}
+}
+
+// Bytes returns 0 since the event carries state update only
+func (ev *PartitionScanEvent) Bytes() int {
+	return 0
+}
+
+func (ev *PartitionScanEvent) ResourceType() string {
+	return ev.Scan.Partition.ResourceType
+}
@@ -2,7 +2,9 @@ package input
 
 import (
 	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -14,6 +16,10 @@ type PartitionState struct {
 	LastIndexed   time.Time
 	LastMtime     time.Time
 	LastRingMtime time.Time
+	// partition scan was interrupted before its last event got persisted
+	Incomplete bool `json:",omitempty"`
+	// last scan that found the partition, even if skipped as unchanged
+	LastSeen time.Time
 }
 
 func NewPartitionState(part *swift.Partition) *PartitionState {
@@ -21,6 +27,7 @@ func NewPartitionState(part *swift.Partition) *PartitionState {
 		LastIndexed:   part.IndexedAt,
 		LastMtime:     part.Mtime,
 		LastRingMtime: part.RingMtime,
+		Incomplete:    part.Incomplete,
 	}
 }
 
@@ -29,10 +36,20 @@ func (ps *PartitionState) Copy() *PartitionState {
 		LastIndexed:   ps.LastIndexed,
 		LastMtime:     ps.LastMtime,
 		LastRingMtime: ps.LastRingMtime,
+		Incomplete:    ps.Incomplete,
+		LastSeen:      ps.LastSeen,
 	}
 	return newPartState
 }
 
+// helper function to return the last time the partition is known to exist
+func (ps *PartitionState) lastActive() time.Time {
+	if ps.LastSeen.After(ps.LastIndexed) {
+		return ps.LastSeen
+	}
+	return ps.LastIndexed
+}
+
 func (ps *PartitionState) update(part *swift.Partition) {
 	if part.IndexedAt.Unix() > ps.LastIndexed.Unix() {
 		ps.LastIndexed = part.IndexedAt
@@ -45,6 +62,8 @@ func (ps *PartitionState) update(part *swift.Partition) {
 	if part.RingMtime.Unix() > ps.LastRingMtime.Unix() {
 		ps.LastRingMtime = part.RingMtime
 	}
+
+	ps.Incomplete = part.Incomplete
 }
 
 // States represent current tracked state for one disk
@@ -52,6 +71,11 @@ type DiskState struct {
 	AccountState   map[string]*PartitionState `json:"account"`
 	ContainerState map[string]*PartitionState `json:"container"`
 	ObjectState    map[string]*PartitionState `json:"object"`
+	// object hash dir states keyed by partition then hash
+	HashState map[string]map[string]*PartitionState `json:"hash,omitempty"`
+	// baseline of the incremental scan of object partitions, tracked
+	// regardless of the events published for the partition
+	ScanState map[string]*PartitionState `json:"scan,omitempty"`
 }
 
 func NewDiskState() *DiskState {
@@ -59,6 +83,8 @@ func NewDiskState() *DiskState {
 		AccountState:   map[string]*PartitionState{},
 		ContainerState: map[string]*PartitionState{},
 		ObjectState:    map[string]*PartitionState{},
+		HashState:      map[string]map[string]*PartitionState{},
+		ScanState:      map[string]*PartitionState{},
 	}
 }
 
@@ -75,6 +101,43 @@ func (ds *DiskState) getResourceState(resType string) map[string]*PartitionState
 	return nil
 }
 
+// helper function to return the state map and key of the partition, or of
+// the hash dir if set. Missing hash state map of the partition is created
+// only if asked to
+func (ds *DiskState) getState(resType string, part *swift.Partition, create bool) (map[string]*PartitionState, string) {
+	if part.Hash == "" {
+		return ds.getResourceState(resType), partitionKey(part)
+	}
+
+	partKey := partitionKey(part)
+	hashState, ok := ds.HashState[partKey]
+	if !ok && create {
+		// registry of old versions comes without hash states
+		if ds.HashState == nil {
+			ds.HashState = map[string]map[string]*PartitionState{}
+		}
+		hashState = map[string]*PartitionState{}
+		ds.HashState[partKey] = hashState
+	}
+	return hashState, part.Hash
+}
+
+// helper function to tell whether any state is tracked for the disk
+func (ds *DiskState) empty() bool {
+	return len(ds.AccountState)+len(ds.ContainerState)+len(ds.ObjectState)+
+		len(ds.HashState)+len(ds.ScanState) == 0
+}
+
+// helper function to return the state key of a partition
+// partitions of non-default storage policies are keyed as <partId>-<policy>
+// to avoid collision with the same partition id under policy 0
+func partitionKey(part *swift.Partition) string {
+	if part.PolicyIndex > 0 {
+		return fmt.Sprintf("%d-%d", part.PartId, part.PolicyIndex)
+	}
+	return strconv.FormatInt(part.PartId, 10)
+}
+
 type States struct {
 	states map[string]*DiskState
 	mutex  sync.Mutex
@@ -88,11 +151,14 @@ func NewStates() *States {
 
 func (s *States) findPrevious(ev Event) *PartitionState {
 	part := ev.ToPartition()
+	if part == nil {
+		return nil
+	}
+
 	if diskState, ok := s.states[part.Device]; ok {
 		resType := ev.ResourceType()
-		resState := diskState.getResourceState(resType)
+		resState, partId := diskState.getState(resType, part, false)
 
-		partId := strconv.FormatInt(part.PartId, 10)
 		if partState, ok := resState[partId]; ok {
 			return partState
 		} else {
@@ -110,9 +176,48 @@ func (s *States) FindPrevious(ev Event) *PartitionState {
 	return s.findPrevious(ev)
 }
 
+// FindPartitionState returns a copy of the state tracked for the partition
+func (s *States) FindPartitionState(part *swift.Partition) *PartitionState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	diskState, ok := s.states[part.Device]
+	if !ok {
+		return nil
+	}
+
+	resState := diskState.getResourceState(part.ResourceType)
+	if partState, ok := resState[partitionKey(part)]; ok {
+		return partState.Copy()
+	}
+	return nil
+}
+
+// FindScanState returns a copy of the incremental scan state of the object
+// partition
+func (s *States) FindScanState(part *swift.Partition) *PartitionState {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	diskState, ok := s.states[part.Device]
+	if !ok {
+		return nil
+	}
+
+	if scanState, ok := diskState.ScanState[partitionKey(part)]; ok {
+		return scanState.Copy()
+	}
+	return nil
+}
+
 // helper function to determine whether need to update partition state
 func isNewerThanPartState(partState *PartitionState, part *swift.Partition, ttl time.Duration) bool {
 
+	if partState.Incomplete {
+		// previous scan interrupted in the middle, index the partition again
+		return true
+	}
+
 	if part.RingMtime.Unix() > partState.LastRingMtime.Unix() {
 		// side effect to purge old state happens separately
 		return true
@@ -150,6 +255,11 @@ func (s *States) IsNewEvent(ev Event) bool {
 	defer s.mutex.Unlock()
 
 	part := ev.ToPartition()
+	// events not bound to a partition are not tracked
+	if part == nil {
+		return true
+	}
+
 	partState := s.findPrevious(ev)
 
 	if partState != nil {
@@ -164,7 +274,22 @@ func (s *States) Update(ev Event) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	// states no longer needed are removed
+	if removal, ok := ev.(*StateRemovalEvent); ok {
+		s.remove(removal.Removal)
+		return nil
+	}
+
+	if scan, ok := ev.(*PartitionScanEvent); ok {
+		s.recordScan(scan.Scan)
+		return nil
+	}
+
 	part := ev.ToPartition()
+	if part == nil {
+		return nil
+	}
+
 	//logp.Debug("hack", "11--> : %s - %s", ev.ToMapStr()["path"], part.Mtime)
 	partState := s.findPrevious(ev)
 
@@ -187,13 +312,12 @@ func (s *States) Update(ev Event) error {
 		}
 	} else {
 		resType := ev.ResourceType()
-		partId := strconv.FormatInt(part.PartId, 10)
 
 		// insert new disk state
 		diskState, found := s.states[part.Device]
 		if !found {
 			diskState := NewDiskState()
-			resState := diskState.getResourceState(resType)
+			resState, partId := diskState.getState(resType, part, true)
 
 			partState := NewPartitionState(part)
 			resState[partId] = partState
@@ -203,7 +327,7 @@ func (s *States) Update(ev Event) error {
 		}
 
 		// disk state exists, inserting new partition state
-		resState := diskState.getResourceState(resType)
+		resState, partId := diskState.getState(resType, part, true)
 		if _, found := resState[partId]; !found {
 			partState := NewPartitionState(part)
 			resState[partId] = partState
@@ -218,6 +342,218 @@ func (s *States) Update(ev Event) error {
 	return errors.New("state update: unknown")
 }
 
+// recordScan advances the incremental scan state of a walked partition, and
+// refreshes LastSeen of all states of the partition so they are not pruned
+// as inactive while the partition is skipped as unchanged
+func (s *States) recordScan(scan PartitionScan) {
+	part := scan.Partition
+
+	diskState, ok := s.states[part.Device]
+	if !ok {
+		diskState = NewDiskState()
+		s.states[part.Device] = diskState
+	}
+	// registry of old versions comes without scan states
+	if diskState.ScanState == nil {
+		diskState.ScanState = map[string]*PartitionState{}
+	}
+
+	partKey := partitionKey(part)
+	scanState, ok := diskState.ScanState[partKey]
+	if !scan.Unchanged {
+		if ok {
+			scanState.update(part)
+		} else {
+			scanState = NewPartitionState(part)
+			diskState.ScanState[partKey] = scanState
+		}
+	}
+	if scanState == nil {
+		return
+	}
+
+	seen := part.IndexedAt
+	scanState.LastSeen = seen
+	if partState, ok := diskState.getResourceState(part.ResourceType)[partKey]; ok {
+		partState.LastSeen = seen
+	}
+
+	// hash dirs are neither added nor removed under an unchanged partition
+	hashState := diskState.HashState[partKey]
+	if scan.Unchanged {
+		for _, hashDirState := range hashState {
+			hashDirState.LastSeen = seen
+		}
+		return
+	}
+	for _, hash := range scan.Hashes {
+		if hashDirState, ok := hashState[hash]; ok {
+			hashDirState.LastSeen = seen
+		}
+	}
+}
+
+// remove deletes the partition or hash dir state, or all states of the
+// device if no partition is given. Hash dir states are removed along with
+// their object partition. Empty disk state is dropped
+func (s *States) remove(removal StateRemoval) {
+	diskState, ok := s.states[removal.Device]
+	if !ok {
+		return
+	}
+
+	if removal.ResourceType != "" {
+		partKey, hash := splitKey(removal.Key)
+		if hash != "" {
+			delete(diskState.HashState[partKey], hash)
+			if len(diskState.HashState[partKey]) == 0 {
+				delete(diskState.HashState, partKey)
+			}
+		} else {
+			resState := diskState.getResourceState(removal.ResourceType)
+			delete(resState, partKey)
+			if removal.ResourceType == "object" {
+				delete(diskState.HashState, partKey)
+				delete(diskState.ScanState, partKey)
+			}
+		}
+		if !diskState.empty() {
+			return
+		}
+	}
+
+	logp.Debug("state", "Remove states of device %s", removal.Device)
+	delete(s.states, removal.Device)
+}
+
+// helper function to split a state key into the partition key and the hash
+// dir, which is empty for partition states
+func splitKey(key string) (string, string) {
+	if i := strings.Index(key, "/"); i >= 0 {
+		return key[:i], key[i+1:]
+	}
+	return key, ""
+}
+
+// helper function to return the storage policy of a partition state key
+func keyPolicy(key string) int64 {
+	key, _ = splitKey(key)
+	if i := strings.Index(key, "-"); i >= 0 {
+		if policy, err := strconv.ParseInt(key[i+1:], 10, 64); err == nil {
+			return policy
+		}
+	}
+	return 0
+}
+
+// helper function to return the object partition keys tracked by hash dir or
+// scan states only
+func (ds *DiskState) objectOnlyKeys() []string {
+	found := map[string]bool{}
+	var keys []string
+	for key := range ds.HashState {
+		if _, ok := ds.ObjectState[key]; !ok && !found[key] {
+			found[key] = true
+			keys = append(keys, key)
+		}
+	}
+	for key := range ds.ScanState {
+		if _, ok := ds.ObjectState[key]; !ok && !found[key] {
+			found[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// StaleStates returns removals for partition states of the device resource
+// and policy which are not found in the given partition list
+func (s *States) StaleStates(device string, resType string, policy int64, partIds []int64) []StateRemoval {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	diskState, ok := s.states[device]
+	if !ok {
+		return nil
+	}
+
+	found := map[string]bool{}
+	for _, partId := range partIds {
+		found[partitionKey(&swift.Partition{PartId: partId, PolicyIndex: policy})] = true
+	}
+
+	var keys []string
+	for key := range diskState.getResourceState(resType) {
+		keys = append(keys, key)
+	}
+	// hash dir and scan states are tracked without the partition state if
+	// object partition index is disabled
+	if resType == "object" {
+		keys = append(keys, diskState.objectOnlyKeys()...)
+	}
+
+	var removals []StateRemoval
+	for _, key := range keys {
+		if keyPolicy(key) == policy && !found[key] {
+			removals = append(removals, StateRemoval{
+				Device:       device,
+				ResourceType: resType,
+				Key:          key,
+			})
+		}
+	}
+	return removals
+}
+
+// InactiveStates returns removals for partition states of the device which
+// are not updated for longer than the given duration
+func (s *States) InactiveStates(device string, inactive time.Duration) []StateRemoval {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	diskState, ok := s.states[device]
+	if !ok {
+		return nil
+	}
+
+	var removals []StateRemoval
+	for _, resType := range []string{"account", "container", "object"} {
+		for key, partState := range diskState.getResourceState(resType) {
+			if time.Since(partState.lastActive()) > inactive {
+				removals = append(removals, StateRemoval{
+					Device:       device,
+					ResourceType: resType,
+					Key:          key,
+				})
+			}
+		}
+	}
+	for key, scanState := range diskState.ScanState {
+		if _, ok := diskState.ObjectState[key]; ok {
+			continue
+		}
+		if time.Since(scanState.lastActive()) > inactive {
+			removals = append(removals, StateRemoval{
+				Device:       device,
+				ResourceType: "object",
+				Key:          key,
+			})
+		}
+	}
+	for partKey, hashState := range diskState.HashState {
+		for hash, hashDirState := range hashState {
+			if time.Since(hashDirState.lastActive()) > inactive {
+				removals = append(removals, StateRemoval{
+					Device:       device,
+					ResourceType: "object",
+					Key:          partKey + "/" + hash,
+				})
+			}
+		}
+	}
+	return removals
+}
+
 // Count returns number of states
 func (s *States) Count() int {
 	s.mutex.Lock()
@@ -248,10 +584,26 @@ func (s *States) GetStatesCopy() map[string]*DiskState {
 			newOState[ok] = ov.Copy()
 		}
 
+		newHState := map[string]map[string]*PartitionState{}
+		for pk, pv := range v.HashState {
+			hashState := map[string]*PartitionState{}
+			for hk, hv := range pv {
+				hashState[hk] = hv.Copy()
+			}
+			newHState[pk] = hashState
+		}
+
+		newSState := map[string]*PartitionState{}
+		for sk, sv := range v.ScanState {
+			newSState[sk] = sv.Copy()
+		}
+
 		newDiskState := &DiskState{
 			AccountState:   newAState,
 			ContainerState: newCState,
 			ObjectState:    newOState,
+			HashState:      newHState,
+			ScanState:      newSState,
 		}
 		newStates[k] = newDiskState
 	}
@@ -277,7 +629,7 @@ func dumpResourceStates(rs map[string]*PartitionState) {
 	logp.Debug("state", "dump stat:")
 	for k, v := range rs {
 		logp.Debug("state", "        key: %s", k)
-		logp.Debug("state", "         val: %s", v)
+		logp.Debug("state", "         val: %+v", v)
 	}
 }
 
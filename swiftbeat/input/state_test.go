@@ -0,0 +1,75 @@
+// +build !integration
+
+package input
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+func TestPartitionKey(t *testing.T) {
+	tests := []struct {
+		part   swift.Partition
+		key    string
+		policy int64
+	}{
+		{swift.Partition{PartId: 12}, "12", 0},
+		{swift.Partition{PartId: 12, PolicyIndex: 2}, "12-2", 2},
+		{swift.Partition{PartId: 12, PolicyIndex: 2, Hash: "abc"}, "12-2", 2},
+	}
+
+	for _, test := range tests {
+		key := partitionKey(&test.part)
+		assert.Equal(t, test.key, key)
+		assert.Equal(t, test.policy, keyPolicy(key))
+		assert.Equal(t, test.policy, keyPolicy(key+"/abc"))
+	}
+}
+
+func TestInactiveStatesKeepsSkippedPartitions(t *testing.T) {
+	old := time.Now().Add(-time.Hour)
+	part := func(partId int64, hash string) *swift.Partition {
+		return &swift.Partition{
+			PartId:       partId,
+			Device:       "sdb",
+			ResourceType: "object",
+			Hash:         hash,
+			IndexedAt:    old,
+			Mtime:        old,
+		}
+	}
+
+	states := NewStates()
+	for _, p := range []*swift.Partition{part(1, ""), part(1, "abc"), part(2, "")} {
+		states.Update(NewObjectPartitionEvent(swift.ObjectPartition{Partition: p}))
+	}
+	states.Update(NewPartitionScanEvent(PartitionScan{Partition: part(1, "")}))
+	states.Update(NewPartitionScanEvent(PartitionScan{Partition: part(2, "")}))
+
+	// partition 1 is seen unchanged by the current scan
+	seen := part(1, "")
+	seen.IndexedAt = time.Now()
+	states.Update(NewPartitionScanEvent(PartitionScan{Partition: seen, Unchanged: true}))
+
+	var keys []string
+	for _, removal := range states.InactiveStates("sdb", time.Minute) {
+		keys = append(keys, removal.Key)
+	}
+	sort.Strings(keys)
+	assert.Equal(t, []string{"2"}, keys)
+
+	// baseline of the skipped partition is kept
+	scanState := states.FindScanState(part(1, ""))
+	if assert.NotNil(t, scanState) {
+		assert.Equal(t, old.Unix(), scanState.LastIndexed.Unix())
+	}
+
+	states.Update(NewStateRemovalEvent(StateRemoval{Device: "sdb", ResourceType: "object", Key: "2"}))
+	assert.Nil(t, states.FindScanState(part(2, "")))
+	assert.Nil(t, states.FindPartitionState(part(2, "")))
+}
@@ -10,4 +10,5 @@ type Account struct {
 	ContainerCount int64
 	ObjectCount    int64
 	BytesUsedMB    int64
+	BytesUsed      int64
 }
@@ -0,0 +1,22 @@
+package swift
+
+import (
+	"time"
+)
+
+// AsyncPending models container updates pending in one suffix dir of the
+// async_pending dir of a device, the oldest update is sampled
+type AsyncPending struct {
+	Device          string
+	PolicyIndex     int64
+	Suffix          string
+	Path            string
+	NumUpdates      int64
+	OldestMtime     time.Time
+	SamplePath      string
+	SampleOp        string
+	SampleAccount   string
+	SampleContainer string
+	SampleObject    string
+	ScannedAt       time.Time
+}
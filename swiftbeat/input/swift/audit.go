@@ -0,0 +1,16 @@
+package swift
+
+import (
+	"time"
+)
+
+// ObjectAudit models a consistency audit finding on an object hash dir
+type ObjectAudit struct {
+	*Partition
+	Hash      string
+	Suffix    string
+	Path      string
+	Finding   string
+	Detail    string
+	AuditedAt time.Time
+}
@@ -1,5 +1,7 @@
 package swift
 
+import "time"
+
 // Container models all necessary info regarding an container event
 type Container struct {
 	*Partition
@@ -10,5 +12,13 @@ type Container struct {
 	Status      string
 	ObjectCount int64
 	BytesUsedMB int64
+	BytesUsed   int64
 	PolicyIndex int64
+	PendingSize int64
+	// deep stats, ObjectRows is -1 if not collected
+	ObjectRows   int64
+	DeletedRows  int64
+	OldestObject time.Time
+	NewestObject time.Time
+	ShardRanges  map[string]int64
 }
@@ -0,0 +1,13 @@
+package swift
+
+import (
+	"time"
+)
+
+// DeviceChange models a device added, removed or unmounted under device_dir
+type DeviceChange struct {
+	Device     string
+	Path       string
+	Change     string
+	DetectedAt time.Time
+}
@@ -0,0 +1,20 @@
+package swift
+
+import (
+	"time"
+)
+
+// DeviceNotInRing models a local device not found in the ring for the
+// configured bind ip and port
+type DeviceNotInRing struct {
+	ResourceType string
+	PolicyIndex  int64
+	PolicyName   string
+	Device       string
+	BindIps      []string
+	BindPort     int64
+	RingMtime    time.Time
+	RingCKSum    string
+	Skipped      bool
+	DetectedAt   time.Time
+}
@@ -0,0 +1,26 @@
+package swift
+
+import (
+	"time"
+)
+
+// HandoffSummary models handoff partition drain stats of a device per scan
+type HandoffSummary struct {
+	ResourceType      string
+	PolicyIndex       int64
+	PolicyName        string
+	Device            string
+	Ip                string
+	ScannedAt         time.Time
+	PrevScannedAt     time.Time
+	RingMtime         time.Time
+	NumParts          int64
+	NumHandoffParts   int64
+	NumDatafiles      int64
+	BytesTotalMB      int64
+	NumDrainedParts   int64
+	NumNewParts       int64
+	DrainPartsPerHour float64
+	DrainMBPerHour    float64
+	DrainETAHours     float64
+}
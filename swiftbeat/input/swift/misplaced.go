@@ -0,0 +1,19 @@
+package swift
+
+import (
+	"time"
+)
+
+// MisplacedObject models an object stored in a partition or hash dir other
+// than the one computed from its name with the ring
+type MisplacedObject struct {
+	*Partition
+	Name           string
+	Hash           string
+	Path           string
+	ExpectedPartId int64
+	ExpectedHash   string
+	PrimaryDevices []string
+	PrimaryIps     []string
+	CheckedAt      time.Time
+}
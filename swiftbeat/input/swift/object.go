@@ -22,22 +22,40 @@ type Object struct {
 	Device         string            `indexer:"Disk" field:"Name"`
 	Handoff        bool              `indexer:"Partition" field:"Handoff"`
 	Ip             string            `indexer:"Resource" field:"Ip"`
-	PeerDevices    string
-	PeerIps        string
+	PolicyIndex    int64             `indexer:"Resource" field:"PolicyIndex"`
+	PolicyName     string            `indexer:"Resource" field:"PolicyName"`
+	PeerDevices    []string
+	PeerIps        []string
+	// fragment archive info of EC policies, FragIndex is -1 otherwise
+	FragIndex     int64 `indexer:"Datafile" field:"FragIndex"`
+	Durable       bool  `indexer:"Datafile" field:"Durable"`
+	FragMisplaced bool  `indexer:"Datafile" field:"FragMisplaced"`
+	Expired       bool  `indexer:"Datafile" field:"Expired"`
+	// partition the object is found in, for state tracking
+	Part *Partition
 }
 
 type ObjectPartition struct {
 	*Partition
-	NumDatafiles  int64
-	NumTombstones int64
-	BytesTotalMB  int64
+	NumDatafiles       int64
+	NumTombstones      int64
+	BytesTotalMB       int64
+	HashesMtime        time.Time
+	NumSuffixes        int64
+	NumInvalidSuffixes int64
+	NumMissingSuffixes int64
+	NumNonDurableFrags int64
+	NumMisplacedFrags  int64
+	NumExpired         int64
+	ExpiredBytes       int64
 }
 
 // Annotate copies info fields from indexer based on struct tag and reflection
+// indexer is passed by pointer so the locks it holds are not copied
 func (o *Object) Annotate(indexer interface{}) {
 
-	indexerType := reflect.TypeOf(indexer)
-	indexerValue := reflect.ValueOf(indexer)
+	indexerValue := reflect.Indirect(reflect.ValueOf(indexer))
+	indexerType := indexerValue.Type()
 
 	objType := reflect.TypeOf(*o)
 	objValue := reflect.ValueOf(o)
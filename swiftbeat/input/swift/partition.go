@@ -15,7 +15,13 @@ type Partition struct {
 	RingMtime    time.Time
 	Handoff      bool
 	ReplicaId    int64
-	PeerDevices  string
-	PeerIps      string
+	PeerDevices  []string
+	PeerIps      []string
 	RingCKSum    string
+	PolicyIndex  int64
+	PolicyName   string
+	// set on all but the last event of a partition in one scan
+	Incomplete bool
+	// set if the state is tracked for a hash dir under the partition
+	Hash string
 }
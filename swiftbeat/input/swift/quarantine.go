@@ -0,0 +1,18 @@
+package swift
+
+import (
+	"time"
+)
+
+// Quarantine models items quarantined on a device for one quarantine type,
+// which is the resource dir name the items are quarantined from
+type Quarantine struct {
+	Device         string
+	QuarantineType string
+	ResourceType   string
+	PolicyIndex    int64
+	NumItems       int64
+	OldestMtime    time.Time
+	NewestMtime    time.Time
+	ScannedAt      time.Time
+}
@@ -0,0 +1,29 @@
+package swift
+
+import (
+	"time"
+)
+
+// ReplicaHealth models the comparison of a primary partition against its
+// replicas on the peer devices
+type ReplicaHealth struct {
+	*Partition
+	Source      string
+	NumSuffixes int64
+	NumHealthy  int64
+	Peers       []PeerReplica
+	CheckedAt   time.Time
+}
+
+// PeerReplica models the partition replica found on one peer device
+type PeerReplica struct {
+	Device                string
+	Ip                    string
+	Port                  int64
+	Status                string
+	Detail                string
+	NumSuffixes           int64
+	NumMismatchedSuffixes int64
+	NumMissingSuffixes    int64
+	ReplicationLast       time.Time
+}
@@ -0,0 +1,22 @@
+package swift
+
+import (
+	"time"
+)
+
+// RingChange models a ring update detected between two scans on a device
+type RingChange struct {
+	ResourceType    string
+	PolicyIndex     int64
+	PolicyName      string
+	Device          string
+	Ip              string
+	DetectedAt      time.Time
+	RingMtime       time.Time
+	RingCKSum       string
+	PrevRingMtime   time.Time
+	PrevRingCKSum   string
+	PartsMovedIn    []int64
+	PartsMovedOut   []int64
+	PartsReassigned []int64
+}
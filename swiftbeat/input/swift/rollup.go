@@ -0,0 +1,27 @@
+package swift
+
+import (
+	"time"
+)
+
+// DBRollup models all local replicas of an account or container db seen
+// during one scan
+type DBRollup struct {
+	ResourceType      string
+	Account           string
+	Container         string
+	NumReplicas       int64
+	NumHandoffs       int64
+	NumDeleted        int64
+	Devices           []string
+	Partitions        []int64
+	Statuses          []string
+	ContainerCountMin int64
+	ContainerCountMax int64
+	ObjectCountMin    int64
+	ObjectCountMax    int64
+	BytesUsedMin      int64
+	BytesUsedMax      int64
+	Consistent        bool
+	ScannedAt         time.Time
+}
@@ -0,0 +1,26 @@
+package swift
+
+import (
+	"time"
+)
+
+// ScanSummary models progress and counters of one scan of a device resource
+type ScanSummary struct {
+	ResourceType   string
+	PolicyIndex    int64
+	PolicyName     string
+	Device         string
+	Ip             string
+	ReportedAt     time.Time
+	StartedAt      time.Time
+	FinishedAt     time.Time
+	Finished       bool
+	DurationMs     int64
+	NumParts       int64
+	PartsScanned   int64
+	PartsSkipped   int64
+	SuffixesWalked int64
+	HashesWalked   int64
+	FilesIndexed   int64
+	Errors         map[string]int64
+}
@@ -0,0 +1,19 @@
+package swift
+
+import (
+	"time"
+)
+
+// TmpDir models files left in the tmp dir of a device, files older than the
+// stale age are most likely leftovers of failed writes
+type TmpDir struct {
+	Device      string
+	Path        string
+	NumFiles    int64
+	BytesTotal  int64
+	NumStale    int64
+	StaleBytes  int64
+	StaleFiles  []string
+	OldestMtime time.Time
+	ScannedAt   time.Time
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"regexp"
 	"time"
+
+	"github.com/elastic/beats/libbeat/common"
+	"github.com/elastic/beats/swiftbeat/indexer"
 )
 
 var (
@@ -13,6 +16,11 @@ var (
 		CleanInactive: 0,
 		CleanRemoved:  false,
 		RescanOlder:   -1 * time.Second,
+		DBRollup:      true,
+		MaxWorkers:    0,
+		QueueSize:     100,
+		MountCheck:    true,
+		SwiftConfig:   indexer.DefaultSwiftConfig,
 	}
 )
 
@@ -24,6 +32,14 @@ type prospectorConfig struct {
 	RescanOlder   time.Duration    `config:"rescan_older"`
 	CleanInactive time.Duration    `config:"clean_inactive" validate:"min=0"`
 	CleanRemoved  bool             `config:"clean_removed"`
+	Indexer       *common.Config   `config:"indexer"`
+	DBRollup      bool             `config:"db_rollup"`
+	MaxWorkers    int              `config:"max_workers" validate:"min=0"`
+	QueueSize     int              `config:"queue_size" validate:"min=0"`
+	MountCheck    bool             `config:"mount_check"`
+
+	// swift_dir, ring_dir and swift_conf settings
+	indexer.SwiftConfig `config:",inline"`
 }
 
 func (config *prospectorConfig) Validate() error {
@@ -32,6 +48,15 @@ func (config *prospectorConfig) Validate() error {
 		return fmt.Errorf("No device dir was defined for prospector")
 	}
 
+	if config.SwiftDir == "" && (config.RingDir == "" || config.SwiftConf == "") {
+		return fmt.Errorf("ring_dir and swift_conf must be set when swift_dir is empty")
+	}
+
+	// conflicting indexer settings would fail every disk prospector
+	if err := indexer.ValidateConfig(config.Indexer); err != nil {
+		return fmt.Errorf("invalid indexer config: %v", err)
+	}
+
 	if config.CleanInactive != 0 && config.IgnoreOlder == 0 {
 		return fmt.Errorf("ignore_older must be enabled when clean_older is used.")
 	}
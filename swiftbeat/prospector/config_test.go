@@ -0,0 +1,61 @@
+// +build !integration
+
+package prospector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/libbeat/common"
+)
+
+func TestProspectorConfigIndexer(t *testing.T) {
+	tests := []struct {
+		name    string
+		indexer map[string]interface{}
+		err     bool
+	}{
+		{name: "defaults"},
+		{
+			name:    "datafile index walking hash dirs",
+			indexer: map[string]interface{}{"enable_datafile_index": true, "partition_index_only": false},
+		},
+		{
+			name:    "datafile index of partitions only",
+			indexer: map[string]interface{}{"enable_datafile_index": true},
+			err:     true,
+		},
+		{
+			name: "object override conflicting",
+			indexer: map[string]interface{}{
+				"object": map[string]interface{}{"enable_audit": true},
+			},
+			err: true,
+		},
+		{
+			name:    "invalid not_in_ring",
+			indexer: map[string]interface{}{"not_in_ring": "drop"},
+			err:     true,
+		},
+	}
+
+	for _, test := range tests {
+		settings := map[string]interface{}{"device_dir": "/srv/node"}
+		if test.indexer != nil {
+			settings["indexer"] = test.indexer
+		}
+		cfg, err := common.NewConfigFrom(settings)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		config := defaultConfig
+		err = cfg.Unpack(&config)
+		if test.err {
+			assert.Error(t, err, test.name)
+		} else {
+			assert.NoError(t, err, test.name)
+		}
+	}
+}
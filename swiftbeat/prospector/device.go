@@ -0,0 +1,160 @@
+package prospector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+// deviceInfo keeps what was seen of a device entry under device_dir
+type deviceInfo struct {
+	mounted bool
+	// id of the underlying filesystem to tell a replaced disk
+	dev uint64
+}
+
+// ismount tells whether path is a mount point the same way Swift does,
+// a mount point is on a different device than its parent or is the root
+func ismount(path string) (bool, error) {
+	fi, err := os.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+	// symlinks are never mount points
+	if fi.Mode()&os.ModeSymlink != 0 {
+		return false, nil
+	}
+
+	parent, err := os.Lstat(filepath.Join(path, ".."))
+	if err != nil {
+		return false, err
+	}
+
+	st := fi.Sys().(*syscall.Stat_t)
+	parentSt := parent.Sys().(*syscall.Stat_t)
+	return st.Dev != parentSt.Dev || st.Ino == parentSt.Ino, nil
+}
+
+// probeDevice returns the state of the device entry, entries other than dirs
+// are skipped with error
+func probeDevice(path string, mountCheck bool) (deviceInfo, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return deviceInfo{}, err
+	}
+	if !fi.IsDir() {
+		return deviceInfo{}, syscall.ENOTDIR
+	}
+
+	info := deviceInfo{
+		mounted: true,
+		dev:     uint64(fi.Sys().(*syscall.Stat_t).Dev),
+	}
+	if mountCheck {
+		mounted, err := ismount(path)
+		if err != nil {
+			return deviceInfo{}, err
+		}
+		info.mounted = mounted
+	}
+	return info, nil
+}
+
+// addDevice starts monitoring the device with a new disk prospector
+func (p *Prospector) addDevice(name string, path string) {
+	prospectorer := NewDiskProspector(p, name, path)
+	if err := prospectorer.Init(); err != nil {
+		logp.Warn("Prospector: failed to initialize prospector for %s: %v", path, err)
+		return
+	}
+	p.prospectorers[name] = prospectorer
+}
+
+// removeDevice stops monitoring the device, scan already in progress is
+// left to finish on its own. Registry states of the device are dropped by the
+// state removal event updateDevices publishes along with a removed device
+// change if clean_removed is set
+func (p *Prospector) removeDevice(name string) {
+	delete(p.prospectorers, name)
+}
+
+// updateDevices enumerates device_dir and adds or removes disk prospectors
+// for devices that appeared, disappeared, got unmounted or replaced since the
+// last enumeration. It returns the device change events
+func (p *Prospector) updateDevices() []input.Event {
+	files, err := ioutil.ReadDir(p.config.DeviceDir)
+	if err != nil {
+		logp.Err("list dir(%s) failed: %v", p.config.DeviceDir, err)
+		return nil
+	}
+
+	// devices found at startup are not reported as added
+	initial := p.devices == nil
+	devices := map[string]deviceInfo{}
+
+	var events []input.Event
+	newEvent := func(name string, path string, change string) {
+		logp.Info("Prospector: device %s %s", name, change)
+		events = append(events, input.NewDeviceEvent(swift.DeviceChange{
+			Device:     name,
+			Path:       path,
+			Change:     change,
+			DetectedAt: time.Now(),
+		}))
+
+		// states of a removed or replaced disk are stale
+		if change == "removed" && p.config.CleanRemoved {
+			events = append(events, input.NewStateRemovalEvent(input.StateRemoval{
+				Device: name,
+			}))
+		}
+	}
+
+	for _, file := range files {
+		name := file.Name()
+		path := filepath.Join(p.config.DeviceDir, name)
+
+		info, err := probeDevice(path, p.config.MountCheck)
+		if err != nil {
+			logp.Debug("prospector", "Skip device entry %s: %v", path, err)
+			continue
+		}
+		devices[name] = info
+
+		prev, known := p.devices[name]
+		switch {
+		case !info.mounted:
+			if !known || prev.mounted {
+				newEvent(name, path, "unmounted")
+				p.removeDevice(name)
+			}
+		case known && prev.mounted && prev.dev != info.dev:
+			// disk replaced, start over with fresh indexer state
+			newEvent(name, path, "removed")
+			p.removeDevice(name)
+			newEvent(name, path, "added")
+			p.addDevice(name, path)
+		case !known || !prev.mounted:
+			if !initial || known {
+				newEvent(name, path, "added")
+			}
+			p.addDevice(name, path)
+		}
+	}
+
+	for name := range p.devices {
+		if _, ok := devices[name]; !ok {
+			newEvent(name, filepath.Join(p.config.DeviceDir, name), "removed")
+			p.removeDevice(name)
+		}
+	}
+
+	p.devices = devices
+	return events
+}
@@ -0,0 +1,106 @@
+// +build !integration
+
+package prospector
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+)
+
+// deviceChanges returns the device changes and devices of state removals
+// of the events in order
+func deviceChanges(events []input.Event) []string {
+	var changes []string
+	for _, event := range events {
+		switch ev := event.(type) {
+		case *input.DeviceEvent:
+			changes = append(changes, ev.Change.Device+" "+ev.Change.Change)
+		case *input.StateRemovalEvent:
+			changes = append(changes, ev.Removal.Device+" states removed")
+		}
+	}
+	return changes
+}
+
+func TestUpdateDevices(t *testing.T) {
+	tests := []struct {
+		name         string
+		cleanRemoved bool
+		add          []string
+		remove       []string
+		changes      []string
+		devices      []string
+	}{
+		{
+			name:    "devices found at startup are not reported",
+			add:     []string{"sdb", "sdc"},
+			devices: []string{"sdb", "sdc"},
+		},
+		{
+			name:    "nothing changed",
+			devices: []string{"sdb", "sdc"},
+		},
+		{
+			name:    "device added",
+			add:     []string{"sdd"},
+			changes: []string{"sdd added"},
+			devices: []string{"sdb", "sdc", "sdd"},
+		},
+		{
+			name:    "device removed, states kept",
+			remove:  []string{"sdb"},
+			changes: []string{"sdb removed"},
+			devices: []string{"sdc", "sdd"},
+		},
+		{
+			name:         "device removed, states cleaned",
+			cleanRemoved: true,
+			remove:       []string{"sdc"},
+			changes:      []string{"sdc removed", "sdc states removed"},
+			devices:      []string{"sdd"},
+		},
+	}
+
+	dir, err := ioutil.TempDir("", "swiftbeat")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	p := &Prospector{
+		config:        defaultConfig,
+		prospectorers: map[string]Prospectorer{},
+	}
+	p.config.DeviceDir = dir
+	p.config.MountCheck = false
+
+	for _, test := range tests {
+		for _, name := range test.add {
+			if err := os.Mkdir(filepath.Join(dir, name), 0755); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, name := range test.remove {
+			if err := os.Remove(filepath.Join(dir, name)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		p.config.CleanRemoved = test.cleanRemoved
+
+		assert.Equal(t, test.changes, deviceChanges(p.updateDevices()), test.name)
+
+		var devices []string
+		for name := range p.prospectorers {
+			devices = append(devices, name)
+		}
+		sort.Strings(devices)
+		assert.Equal(t, test.devices, devices, test.name)
+	}
+}
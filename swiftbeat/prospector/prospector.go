@@ -1,31 +1,44 @@
 package prospector
 
 import (
+	"expvar"
 	"io/ioutil"
-	"path/filepath"
 	"runtime/debug"
 	"sync"
 	"time"
 
 	"github.com/elastic/beats/libbeat/common"
 	"github.com/elastic/beats/libbeat/logp"
+	"github.com/elastic/beats/swiftbeat/indexer"
 	"github.com/elastic/beats/swiftbeat/input"
 )
 
+var (
+	// queue depth of events from disk indexers keyed by device_dir
+	prospectorStats = expvar.NewMap("prospector")
+)
+
 type Prospector struct {
 	cfg           *common.Config // Raw config
 	config        prospectorConfig
-	prospectorers []Prospectorer
+	prospectorers map[string]Prospectorer
+	devices       map[string]deviceInfo
 	spoolerChan   chan input.Event
 	harvesterChan chan input.Event
+	scanDone      chan []Prospectorer
 	done          chan struct{}
 	states        *input.States
+	rollups       *dbRollups
+	workers       indexer.Semaphore
 	wg            sync.WaitGroup
 }
 
 type Prospectorer interface {
 	Init() error
 	Run()
+	Wait()
+	Device() string
+	PartitionLists() []indexer.PartitionList
 }
 
 func NewProspector(cfg *common.Config, states input.States, spoolerChan chan input.Event) (*Prospector, error) {
@@ -33,9 +46,11 @@ func NewProspector(cfg *common.Config, states input.States, spoolerChan chan inp
 		cfg:           cfg,
 		config:        defaultConfig,
 		spoolerChan:   spoolerChan,
-		harvesterChan: make(chan input.Event),
+		prospectorers: map[string]Prospectorer{},
+		scanDone:      make(chan []Prospectorer),
 		done:          make(chan struct{}),
 		states:        states.Copy(),
+		rollups:       newDBRollups(),
 		wg:            sync.WaitGroup{},
 	}
 
@@ -46,6 +61,12 @@ func NewProspector(cfg *common.Config, states input.States, spoolerChan chan inp
 		return nil, err
 	}
 
+	// events from disk indexers are buffered to smooth out publishing
+	prospector.harvesterChan = make(chan input.Event, prospector.config.QueueSize)
+	prospectorStats.Set(prospector.config.DeviceDir+".queue_depth", expvar.Func(func() interface{} {
+		return len(prospector.harvesterChan)
+	}))
+
 	err := prospector.Init()
 	if err != nil {
 		return nil, err
@@ -59,26 +80,16 @@ func NewProspector(cfg *common.Config, states input.States, spoolerChan chan inp
 // Init sets up default config for prospector
 func (p *Prospector) Init() error {
 
-	files, err := ioutil.ReadDir(p.config.DeviceDir)
+	// partition indexers of all disks share the global limit
+	p.workers = indexer.NewSemaphore(p.config.MaxWorkers)
+
+	// devices are enumerated on every scan, only make sure device_dir is readable
+	_, err := ioutil.ReadDir(p.config.DeviceDir)
 	if err != nil {
 		logp.Err("list dir(%s) failed: %v", p.config.DeviceDir, err)
 		return err
 	}
 
-	for _, file := range files {
-		name := file.Name()
-		path := filepath.Join(p.config.DeviceDir, name)
-		prospectorer := NewDiskProspector(p, name, path)
-
-		err := prospectorer.Init()
-		if err != nil {
-			logp.Warn("Prospector: failed to initialize prospector for: %s", path)
-			continue
-		}
-
-		p.prospectorers = append(p.prospectorers, prospectorer)
-	}
-
 	// Create empty harvester to check if configs are fine
 	//_, err = p.createHarvester(file.State{})
 	//if err != nil {
@@ -104,35 +115,33 @@ func (p *Prospector) Run() {
 			case <-p.done:
 				logp.Info("Prospector channel stopped")
 				return
-			case event := <-p.harvesterChan:
-
-				// Add ttl if RescanOlder is enabled
-				if p.config.RescanOlder > 0 {
-					event.SetTTL(p.config.RescanOlder)
+			case scanned := <-p.scanDone:
+				// events of the scan still queued are handled before the
+				// rollups and states of the scan are finalized
+				if !p.drainEvents() {
+					logp.Info("Prospector channel stopped")
+					return
 				}
-
-				if !p.states.IsNewEvent(event) {
-					continue
+				// all events of the scan are received, publish rollups
+				if !p.publishRollups() {
+					logp.Info("Prospector channel stopped")
+					return
 				}
-
-				//part := event.ToPartition()
-				//logp.Debug("hack", "66--> : %s - %s", event.ToMapStr()["path"], part.Mtime)
-
-				select {
-				case <-p.done:
+				if !p.publishRemovals(p.pruneStates(scanned)) {
+					logp.Info("Prospector channel stopped")
+					return
+				}
+			case event := <-p.harvesterChan:
+				if !p.handleEvent(event) {
 					logp.Info("Prospector channel stopped")
 					return
-				case p.spoolerChan <- event:
-					p.states.Update(event)
 				}
 			}
 		}
 	}()
 
 	// Initial prospector run
-	for _, prospectorer := range p.prospectorers {
-		prospectorer.Run()
-	}
+	p.scan()
 
 	for {
 		select {
@@ -143,17 +152,149 @@ func (p *Prospector) Run() {
 			// force GC and return memory to OS to keep low mem profile
 			debug.FreeOSMemory()
 			logp.Debug("prospector", "Run prospector")
-			for _, prospectorer := range p.prospectorers {
-				prospectorer.Run()
+			p.scan()
+		}
+	}
+}
+
+// scan runs all prospectorers and signals scanDone once all of them finish
+// It blocks until the scan completes so scans never overlap, the next scan
+// is scheduled scan_frequency after the previous one finished
+func (p *Prospector) scan() {
+	// pick up devices added, removed or unmounted since the last scan
+	for _, event := range p.updateDevices() {
+		select {
+		case <-p.done:
+			return
+		case p.harvesterChan <- event:
+		}
+	}
+
+	var running []Prospectorer
+	for _, prospectorer := range p.prospectorers {
+		prospectorer.Run()
+		running = append(running, prospectorer)
+	}
+
+	for _, prospectorer := range running {
+		prospectorer.Wait()
+	}
+
+	select {
+	case <-p.done:
+	case p.scanDone <- running:
+	}
+}
+
+// handleEvent forwards an event from the disk indexers to the spooler unless
+// it is already tracked in states
+// It returns false if prospector is stopped in the meantime
+func (p *Prospector) handleEvent(event input.Event) bool {
+
+	// rollup sees all db events regardless of states
+	if p.config.DBRollup {
+		p.rollups.add(event)
+	}
+
+	// Add ttl if RescanOlder is enabled
+	if p.config.RescanOlder > 0 {
+		event.SetTTL(p.config.RescanOlder)
+	}
+
+	if !p.states.IsNewEvent(event) {
+		return true
+	}
+
+	select {
+	case <-p.done:
+		return false
+	case p.spoolerChan <- event:
+		p.states.Update(event)
+	}
+	return true
+}
+
+// drainEvents handles the events left in harvesterChan. It is called once
+// the scan finished, so no more events are queued in the meantime
+// It returns false if prospector is stopped in the meantime
+func (p *Prospector) drainEvents() bool {
+	for {
+		select {
+		case event := <-p.harvesterChan:
+			if !p.handleEvent(event) {
+				return false
+			}
+		default:
+			return true
+		}
+	}
+}
+
+// publishRollups forwards rollup events of the finished scan to the spooler
+// It returns false if prospector is stopped in the meantime
+func (p *Prospector) publishRollups() bool {
+	for _, event := range p.rollups.flush() {
+		select {
+		case <-p.done:
+			return false
+		case p.spoolerChan <- event:
+		}
+	}
+	return true
+}
+
+// pruneStates returns removals of partition states no longer needed on the
+// devices scanned, according to clean_removed and clean_inactive
+// States of devices not scanned by this prospector are left alone since
+// they might belong to another prospector
+func (p *Prospector) pruneStates(scanned []Prospectorer) []input.StateRemoval {
+	var removals []input.StateRemoval
+	for _, prospectorer := range scanned {
+		device := prospectorer.Device()
+
+		if p.config.CleanRemoved {
+			for _, list := range prospectorer.PartitionLists() {
+				removals = append(removals, p.states.StaleStates(device,
+					list.ResourceType, list.PolicyIndex, list.PartIds)...)
 			}
 		}
+
+		if p.config.CleanInactive > 0 {
+			removals = append(removals, p.states.InactiveStates(device, p.config.CleanInactive)...)
+		}
+	}
+	return removals
+}
+
+// publishRemovals forwards state removals to the registrar through the
+// spooler and applies them on prospector states
+// It returns false if prospector is stopped in the meantime
+func (p *Prospector) publishRemovals(removals []input.StateRemoval) bool {
+	for _, removal := range removals {
+		event := input.NewStateRemovalEvent(removal)
+		select {
+		case <-p.done:
+			return false
+		case p.spoolerChan <- event:
+			p.states.Update(event)
+		}
 	}
+
+	if len(removals) > 0 {
+		logp.Info("Prospector: %d partition states removed", len(removals))
+	}
+	return true
 }
 
 func (p *Prospector) Stop() {
 	logp.Info("Stopping Prospector")
 	close(p.done)
 	p.wg.Wait()
+
+	// in-flight disk scans are cancelled by done, wait for them to exit
+	for _, prospectorer := range p.prospectorers {
+		prospectorer.Wait()
+	}
 }
 
 // createHarvester creates a new harvester instance from the given state
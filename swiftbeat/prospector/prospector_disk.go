@@ -28,7 +28,10 @@ func NewDiskProspector(p *Prospector, name string, path string) *DiskProspector
 func (p *DiskProspector) Init() error {
 
 	disk, err := indexer.NewDisk(p.devName, p.devPath,
-		p.Prospector.harvesterChan, p.Prospector.done)
+		p.config.SwiftConfig, p.config.Indexer,
+		p.Prospector.harvesterChan, p.Prospector.done,
+		p.Prospector.workers, p.Prospector.states,
+		p.config.RescanOlder)
 	if err != nil {
 		return err
 	}
@@ -43,6 +46,21 @@ func (p *DiskProspector) Run() {
 	p.scan()
 }
 
+// Device returns the name of the device monitored
+func (p *DiskProspector) Device() string {
+	return p.devName
+}
+
+// PartitionLists returns partitions found by the last complete disk scan
+func (p *DiskProspector) PartitionLists() []indexer.PartitionList {
+	return p.disk.PartitionLists()
+}
+
+// Wait blocks until the disk scan started by Run finishes
+func (p *DiskProspector) Wait() {
+	p.disk.Wait()
+}
+
 // Scan starts a scanGlob for each provided path/glob
 func (p *DiskProspector) scan() {
 	p.disk.BuildIndex()
@@ -0,0 +1,160 @@
+package prospector
+
+import (
+	"strings"
+	"time"
+
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+const (
+	deletedStatus = "DELETED"
+)
+
+// dbReplica keeps the stats of one local account or container db replica
+type dbReplica struct {
+	device         string
+	partId         int64
+	handoff        bool
+	status         string
+	containerCount int64
+	objectCount    int64
+	bytesUsed      int64
+}
+
+// dbRollups collects db events of one scan grouped by account / container
+type dbRollups struct {
+	resTypes map[string]string
+	replicas map[string][]dbReplica
+}
+
+func newDBRollups() *dbRollups {
+	return &dbRollups{
+		resTypes: map[string]string{},
+		replicas: map[string][]dbReplica{},
+	}
+}
+
+// add collects account and container events, other events are ignored
+func (r *dbRollups) add(event input.Event) {
+	switch ev := event.(type) {
+	case *input.AccountEvent:
+		key := ev.Account.Account
+		r.resTypes[key] = "account"
+		r.replicas[key] = append(r.replicas[key], dbReplica{
+			device:         ev.Account.Device,
+			partId:         ev.Account.PartId,
+			handoff:        ev.Account.Handoff,
+			status:         ev.Account.Status,
+			containerCount: ev.Account.ContainerCount,
+			objectCount:    ev.Account.ObjectCount,
+			bytesUsed:      ev.Account.BytesUsed,
+		})
+	case *input.ContainerEvent:
+		key := ev.Container.Account + "/" + ev.Container.Container
+		r.resTypes[key] = "container"
+		r.replicas[key] = append(r.replicas[key], dbReplica{
+			device:         ev.Container.Device,
+			partId:         ev.Container.PartId,
+			handoff:        ev.Container.Handoff,
+			status:         ev.Container.Status,
+			containerCount: -1,
+			objectCount:    ev.Container.ObjectCount,
+			bytesUsed:      ev.Container.BytesUsed,
+		})
+	}
+}
+
+// flush returns one rollup event per account / container and resets the
+// collected replicas for the next scan
+func (r *dbRollups) flush() []input.Event {
+	var events []input.Event
+	scannedAt := time.Now()
+
+	for key, replicas := range r.replicas {
+		rollup := swift.DBRollup{
+			ResourceType: r.resTypes[key],
+			NumReplicas:  int64(len(replicas)),
+			Consistent:   true,
+			ScannedAt:    scannedAt,
+		}
+
+		if rollup.ResourceType == "account" {
+			rollup.Account = key
+		} else {
+			rollup.Account, rollup.Container = splitContainerKey(key)
+		}
+
+		parts := map[int64]bool{}
+		for i, replica := range replicas {
+			if i == 0 {
+				rollup.ContainerCountMin = replica.containerCount
+				rollup.ContainerCountMax = replica.containerCount
+				rollup.ObjectCountMin = replica.objectCount
+				rollup.ObjectCountMax = replica.objectCount
+				rollup.BytesUsedMin = replica.bytesUsed
+				rollup.BytesUsedMax = replica.bytesUsed
+			}
+
+			rollup.ContainerCountMin = minInt64(rollup.ContainerCountMin, replica.containerCount)
+			rollup.ContainerCountMax = maxInt64(rollup.ContainerCountMax, replica.containerCount)
+			rollup.ObjectCountMin = minInt64(rollup.ObjectCountMin, replica.objectCount)
+			rollup.ObjectCountMax = maxInt64(rollup.ObjectCountMax, replica.objectCount)
+			rollup.BytesUsedMin = minInt64(rollup.BytesUsedMin, replica.bytesUsed)
+			rollup.BytesUsedMax = maxInt64(rollup.BytesUsedMax, replica.bytesUsed)
+
+			if replica.handoff {
+				rollup.NumHandoffs += 1
+			}
+			if replica.status == deletedStatus {
+				rollup.NumDeleted += 1
+			}
+			if replica.status != replicas[0].status {
+				rollup.Consistent = false
+			}
+
+			rollup.Devices = append(rollup.Devices, replica.device)
+			rollup.Statuses = append(rollup.Statuses, replica.status)
+			if !parts[replica.partId] {
+				parts[replica.partId] = true
+				rollup.Partitions = append(rollup.Partitions, replica.partId)
+			}
+		}
+
+		if rollup.ContainerCountMin != rollup.ContainerCountMax ||
+			rollup.ObjectCountMin != rollup.ObjectCountMax ||
+			rollup.BytesUsedMin != rollup.BytesUsedMax {
+			rollup.Consistent = false
+		}
+
+		events = append(events, input.NewDBRollupEvent(rollup))
+	}
+
+	r.resTypes = map[string]string{}
+	r.replicas = map[string][]dbReplica{}
+	return events
+}
+
+// helper function to split container rollup key into account and container
+func splitContainerKey(key string) (string, string) {
+	parts := strings.SplitN(key, "/", 2)
+	if len(parts) != 2 {
+		return key, ""
+	}
+	return parts[0], parts[1]
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt64(a, b int64) int64 {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,145 @@
+// +build !integration
+
+package prospector
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+func accountEvent(device string, partId int64, handoff bool, status string, containers, objects, bytes int64) input.Event {
+	return input.NewAccountEvent(swift.Account{
+		Partition:      &swift.Partition{Device: device, PartId: partId, Handoff: handoff},
+		Account:        "AUTH_a",
+		Status:         status,
+		ContainerCount: containers,
+		ObjectCount:    objects,
+		BytesUsed:      bytes,
+	})
+}
+
+func containerEvent(device string, partId int64, handoff bool, status string, objects, bytes int64) input.Event {
+	return input.NewContainerEvent(swift.Container{
+		Partition:   &swift.Partition{Device: device, PartId: partId, Handoff: handoff},
+		Account:     "AUTH_a",
+		Container:   "c/with/slash",
+		Status:      status,
+		ObjectCount: objects,
+		BytesUsed:   bytes,
+	})
+}
+
+func TestDBRollups(t *testing.T) {
+	tests := []struct {
+		name   string
+		events []input.Event
+		rollup swift.DBRollup
+	}{
+		{
+			name: "consistent account replicas",
+			events: []input.Event{
+				accountEvent("sdb", 3, false, "", 2, 10, 100),
+				accountEvent("sdc", 3, false, "", 2, 10, 100),
+			},
+			rollup: swift.DBRollup{
+				ResourceType:      "account",
+				Account:           "AUTH_a",
+				NumReplicas:       2,
+				Devices:           []string{"sdb", "sdc"},
+				Partitions:        []int64{3},
+				Statuses:          []string{"", ""},
+				ContainerCountMin: 2,
+				ContainerCountMax: 2,
+				ObjectCountMin:    10,
+				ObjectCountMax:    10,
+				BytesUsedMin:      100,
+				BytesUsedMax:      100,
+				Consistent:        true,
+			},
+		},
+		{
+			name: "diverged container replicas",
+			events: []input.Event{
+				containerEvent("sdb", 5, false, "", 10, 100),
+				containerEvent("sdc", 5, false, "", 7, 80),
+				containerEvent("sdd", 9, true, "", 12, 120),
+			},
+			rollup: swift.DBRollup{
+				ResourceType:      "container",
+				Account:           "AUTH_a",
+				Container:         "c/with/slash",
+				NumReplicas:       3,
+				NumHandoffs:       1,
+				Devices:           []string{"sdb", "sdc", "sdd"},
+				Partitions:        []int64{5, 9},
+				Statuses:          []string{"", "", ""},
+				ContainerCountMin: -1,
+				ContainerCountMax: -1,
+				ObjectCountMin:    7,
+				ObjectCountMax:    12,
+				BytesUsedMin:      80,
+				BytesUsedMax:      120,
+				Consistent:        false,
+			},
+		},
+		{
+			name: "deleted replica",
+			events: []input.Event{
+				accountEvent("sdb", 3, false, "", 0, 0, 0),
+				accountEvent("sdc", 3, false, deletedStatus, 0, 0, 0),
+			},
+			rollup: swift.DBRollup{
+				ResourceType: "account",
+				Account:      "AUTH_a",
+				NumReplicas:  2,
+				NumDeleted:   1,
+				Devices:      []string{"sdb", "sdc"},
+				Partitions:   []int64{3},
+				Statuses:     []string{"", deletedStatus},
+				Consistent:   false,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		rollups := newDBRollups()
+		for _, event := range test.events {
+			rollups.add(event)
+		}
+		// events of other types are ignored
+		rollups.add(input.NewScanSummaryEvent(swift.ScanSummary{}))
+
+		events := rollups.flush()
+		if !assert.Len(t, events, 1, test.name) {
+			continue
+		}
+		rollup := events[0].(*input.DBRollupEvent).Rollup
+		test.rollup.ScannedAt = rollup.ScannedAt
+		assert.Equal(t, test.rollup, rollup, test.name)
+
+		// collected replicas are reset for the next scan
+		assert.Empty(t, rollups.flush(), test.name)
+	}
+}
+
+func TestSplitContainerKey(t *testing.T) {
+	tests := []struct {
+		key       string
+		account   string
+		container string
+	}{
+		{"AUTH_a/c", "AUTH_a", "c"},
+		{"AUTH_a/c/with/slash", "AUTH_a", "c/with/slash"},
+		{"AUTH_a", "AUTH_a", ""},
+	}
+
+	for _, test := range tests {
+		account, container := splitContainerKey(test.key)
+		assert.Equal(t, test.account, account, test.key)
+		assert.Equal(t, test.container, container, test.key)
+	}
+}
@@ -172,17 +172,25 @@ func (p *asyncLogPublisher) Start() {
 			case <-p.done:
 				return
 			case events := <-p.in:
-				pubEvents := make([]common.MapStr, len(events))
-				for i, event := range events {
-					pubEvents[i] = event.ToMapStr()
+				pubEvents := make([]common.MapStr, 0, len(events))
+				for _, event := range events {
+					// Only send event with bytes read. 0 Bytes means state update only
+					if event.Bytes() > 0 {
+						pubEvents = append(pubEvents, event.ToMapStr())
+					}
 				}
 
 				batch := &eventsBatch{
 					flag:   0,
 					events: events,
 				}
-				p.client.PublishEvents(pubEvents,
-					publisher.Signal(batch), publisher.Guaranteed)
+				// nothing is signaled by the pipeline for empty batch
+				if len(pubEvents) == 0 {
+					batch.Completed()
+				} else {
+					p.client.PublishEvents(pubEvents,
+						publisher.Signal(batch), publisher.Guaranteed)
+				}
 
 				p.active.append(batch)
 			case <-ticker.C:
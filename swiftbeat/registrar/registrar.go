@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"expvar"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sync"
@@ -26,6 +27,88 @@ var (
 	statesTotal   = expvar.NewInt("registar.states.total")
 )
 
+const (
+	// version 1 is the bare states map written before the envelope
+	registryVersion = 2
+)
+
+// registry is the envelope of the registry file
+type registry struct {
+	Version int                         `json:"version"`
+	States  map[string]*input.DiskState `json:"states"`
+}
+
+// decodeRegistry decodes the registry file content of any known version and
+// migrates it to the current version
+func decodeRegistry(data []byte) (map[string]*input.DiskState, error) {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	version := 1
+	if v, ok := raw["version"]; ok {
+		if err := json.Unmarshal(v, &version); err != nil {
+			// device named version in a version 1 registry
+			version = 1
+		}
+	}
+
+	switch version {
+	case 1:
+		var states map[string]*input.DiskState
+		if err := json.Unmarshal(data, &states); err != nil {
+			return nil, err
+		}
+		logp.Info("Migrating registry from version 1 to %d", registryVersion)
+		return normalizeStates(states), nil
+	case registryVersion:
+		var reg registry
+		if err := json.Unmarshal(data, &reg); err != nil {
+			return nil, err
+		}
+		return normalizeStates(reg.States), nil
+	}
+
+	return nil, fmt.Errorf("unsupported registry version %d", version)
+}
+
+// normalizeStates replaces null or missing states decoded from the registry
+// file by empty ones, so later updates never write to a nil map
+func normalizeStates(states map[string]*input.DiskState) map[string]*input.DiskState {
+	if states == nil {
+		return map[string]*input.DiskState{}
+	}
+
+	for device, ds := range states {
+		if ds == nil {
+			states[device] = input.NewDiskState()
+			continue
+		}
+		if ds.AccountState == nil {
+			ds.AccountState = map[string]*input.PartitionState{}
+		}
+		if ds.ContainerState == nil {
+			ds.ContainerState = map[string]*input.PartitionState{}
+		}
+		if ds.ObjectState == nil {
+			ds.ObjectState = map[string]*input.PartitionState{}
+		}
+		if ds.HashState == nil {
+			ds.HashState = map[string]map[string]*input.PartitionState{}
+		}
+		for key, hashes := range ds.HashState {
+			if hashes == nil {
+				ds.HashState[key] = map[string]*input.PartitionState{}
+			}
+		}
+		if ds.ScanState == nil {
+			ds.ScanState = map[string]*input.PartitionState{}
+		}
+	}
+	return states
+}
+
 func New(registryFile string) (*Registrar, error) {
 
 	r := &Registrar{
@@ -64,8 +147,23 @@ func (r *Registrar) GetStates() input.States {
 	return *r.states
 }
 
+// helper function to return the path of the registry backup file
+func (r *Registrar) backupFile() string {
+	return r.registryFile + ".bak"
+}
+
+// readStates reads and decodes states from the given registry file
+func readStates(path string) (map[string]*input.DiskState, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRegistry(data)
+}
+
 // loadStates fetches the previous reading state from the configure RegistryFile file
-// The default file is `registry` in the data path.
+// The default file is `registry` in the data path. The backup of the previous
+// registry file is used if the registry file is missing or corrupted
 func (r *Registrar) loadStates() error {
 
 	// Check if files exists
@@ -74,27 +172,33 @@ func (r *Registrar) loadStates() error {
 		return err
 	}
 
-	// Error means no file found
-	if err != nil {
-		logp.Info("No registry file found under: %s. Creating a new registry file.", r.registryFile)
-		return nil
-	}
-
-	f, err := os.Open(r.registryFile)
-	if err != nil {
-		return err
+	var states map[string]*input.DiskState
+	if err == nil {
+		logp.Info("Loading registrar data from %s", r.registryFile)
+
+		states, err = readStates(r.registryFile)
+		if err != nil {
+			// keep the corrupted file around for inspection
+			logp.Err("Error decoding states from %s: %s", r.registryFile, err)
+			if err := os.Rename(r.registryFile, r.registryFile+".corrupted"); err != nil {
+				logp.Err("Failed to move corrupted registry file: %s", err)
+			}
+		}
 	}
 
-	defer f.Close()
-
-	logp.Info("Loading registrar data from %s", r.registryFile)
+	if states == nil {
+		backup := r.backupFile()
+		if _, err := os.Stat(backup); err != nil {
+			logp.Info("No registry file found under: %s. Creating a new registry file.", r.registryFile)
+			return nil
+		}
 
-	var states map[string]*input.DiskState
-	decoder := json.NewDecoder(f)
-	err = decoder.Decode(&states)
-	if err != nil {
-		logp.Err("Error decoding states: %s", err)
-		return err
+		logp.Info("Loading registrar data from backup %s", backup)
+		states, err = readStates(backup)
+		if err != nil {
+			logp.Err("Error decoding states from %s: %s. Starting with empty states.", backup, err)
+			return nil
+		}
 	}
 
 	r.states.SetStates(states)
@@ -175,7 +279,10 @@ func (r *Registrar) writeRegistry() error {
 	states := r.states.GetStatesCopy()
 
 	encoder := json.NewEncoder(f)
-	err = encoder.Encode(states)
+	err = encoder.Encode(registry{
+		Version: registryVersion,
+		States:  states,
+	})
 	if err != nil {
 		logp.Err("Error when encoding the states: %s", err)
 		return err
@@ -192,7 +299,18 @@ func (r *Registrar) writeRegistry() error {
 }
 
 // SafeFileRotate safely rotates an existing file under path and replaces it with the tempfile
+// The previous file is kept as backup with a hard link so path always exists
 func (r *Registrar) safeFileRotate(path, tempfile string) error {
+	backup := r.backupFile()
+	if _, err := os.Stat(path); err == nil {
+		if err := os.Remove(backup); err != nil && !os.IsNotExist(err) {
+			logp.Warn("Failed to remove registry backup: %s", err)
+		}
+		if err := os.Link(path, backup); err != nil {
+			logp.Warn("Failed to back up registry file: %s", err)
+		}
+	}
+
 	if e := os.Rename(tempfile, path); e != nil {
 		logp.Err("Rotate error: %s", e)
 		return e
@@ -0,0 +1,123 @@
+// +build !integration
+
+package registrar
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/beats/swiftbeat/input"
+	"github.com/elastic/beats/swiftbeat/input/swift"
+)
+
+func TestDecodeRegistry(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		devices []string
+		objects map[string][]string
+		err     bool
+	}{
+		{
+			name:    "version 1",
+			data:    `{"sdb": {"account": {}, "container": {}, "object": {"12": {"LastIndexed": "2017-03-01T00:00:00Z"}}}}`,
+			devices: []string{"sdb"},
+			objects: map[string][]string{"sdb": {"12"}},
+		},
+		{
+			name:    "version 1 with device named version",
+			data:    `{"version": {"account": {}, "container": {}, "object": {"7-1": {}}}}`,
+			devices: []string{"version"},
+			objects: map[string][]string{"version": {"7-1"}},
+		},
+		{
+			name:    "version 2",
+			data:    `{"version": 2, "states": {"sdb": {"object": {"3": {}}}, "sdc": {"object": {}}}}`,
+			devices: []string{"sdb", "sdc"},
+			objects: map[string][]string{"sdb": {"3"}, "sdc": {}},
+		},
+		{
+			name:    "version 2 empty",
+			data:    `{"version": 2, "states": {}}`,
+			devices: []string{},
+		},
+		{
+			name: "unsupported version",
+			data: `{"version": 3, "states": {}}`,
+			err:  true,
+		},
+		{
+			name: "invalid json",
+			data: `{"version": 2,`,
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		states, err := decodeRegistry([]byte(test.data))
+		if test.err {
+			assert.Error(t, err, test.name)
+			continue
+		}
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+
+		devices := []string{}
+		for device := range states {
+			devices = append(devices, device)
+		}
+		sort.Strings(devices)
+		assert.Equal(t, test.devices, devices, test.name)
+
+		for device, keys := range test.objects {
+			objects := []string{}
+			for key := range states[device].ObjectState {
+				objects = append(objects, key)
+			}
+			assert.Equal(t, keys, objects, test.name)
+		}
+	}
+}
+
+func TestDecodeRegistryNullStates(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "version 1 null device", data: `{"sdb": null}`},
+		{name: "version 1 null states", data: `{"sdb": {"account": null, "container": null, "object": null}}`},
+		{name: "version 2 null states", data: `{"version": 2, "states": null}`},
+		{name: "version 2 missing states", data: `{"version": 2}`},
+		{name: "version 2 null device", data: `{"version": 2, "states": {"sdb": null}}`},
+		{name: "version 2 empty device", data: `{"version": 2, "states": {"sdb": {}}}`},
+		{name: "version 2 null hashes", data: `{"version": 2, "states": {"sdb": {"object": {}, "hash": {"12": null}, "scan": null}}}`},
+	}
+
+	now := time.Now()
+	part := &swift.Partition{PartId: 12, Device: "sdb", ResourceType: "object", IndexedAt: now, Mtime: now}
+	hash := *part
+	hash.Hash = "abc"
+	db := &swift.Partition{PartId: 3, Device: "sdb", ResourceType: "container", IndexedAt: now, Mtime: now}
+
+	for _, test := range tests {
+		decoded, err := decodeRegistry([]byte(test.data))
+		if !assert.NoError(t, err, test.name) {
+			continue
+		}
+
+		states := input.NewStates()
+		states.SetStates(decoded)
+		assert.NotPanics(t, func() {
+			states.Update(input.NewObjectPartitionEvent(swift.ObjectPartition{Partition: part}))
+			states.Update(input.NewObjectEvent(swift.Object{Part: &hash}))
+			states.Update(input.NewContainerEvent(swift.Container{Partition: db}))
+			states.Update(input.NewPartitionScanEvent(input.PartitionScan{Partition: part}))
+			states.Update(input.NewStateRemovalEvent(input.StateRemoval{Device: "sdb", ResourceType: "object", Key: "12"}))
+		}, test.name)
+		assert.NotNil(t, states.FindPartitionState(db), test.name)
+	}
+}